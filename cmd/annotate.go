@@ -0,0 +1,108 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/littleworks-inc/cloudcost/internal/annotate"
+	"github.com/littleworks-inc/cloudcost/internal/controller"
+	"github.com/littleworks-inc/cloudcost/internal/parser/terraform"
+	"github.com/littleworks-inc/cloudcost/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var annotatePath string
+var annotatePatch bool
+
+// annotateCmd represents the annotate command
+var annotateCmd = &cobra.Command{
+	Use:   "annotate",
+	Short: "Show IaC files with the cost of each resource",
+	Long: `Print each IaC file with a monthly cost comment above every resource block
+and a total at the end of each module.
+
+Use --patch to write the annotations as a unified diff instead, which can be
+applied with 'git apply' or 'patch -p1' from the current directory. Running
+annotate again replaces earlier annotations instead of repeating them.
+
+Examples:
+  cloudcost annotate --path .
+  cloudcost annotate --path . --patch --output-file costs.patch
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Check if path exists
+		if _, err := os.Stat(annotatePath); os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist: %s", annotatePath)
+		}
+
+		// Create estimator
+		estimator := controller.NewEstimator()
+
+		// Register parsers
+		estimator.RegisterParser(terraform.NewParser())
+
+		// Register pricing clients
+		if err := registerPricingClients(estimator); err != nil {
+			return err
+		}
+
+		// Load usage estimates
+		if usageFile != "" {
+			usageData, err := usage.Load(usageFile)
+			if err != nil {
+				return err
+			}
+			estimator.Usage = usageData
+		}
+
+		report, err := estimator.Estimate(annotatePath)
+		if err != nil {
+			return fmt.Errorf("estimation failed: %v", err)
+		}
+
+		files, err := annotate.Annotate(report.Resources)
+		if err != nil {
+			return err
+		}
+
+		writer, closeOutput, err := createOutput(outputFile)
+		if err != nil {
+			return err
+		}
+
+		for i, file := range files {
+			if annotatePatch {
+				err = file.WritePatch(writer)
+			} else {
+				if i > 0 {
+					fmt.Fprintln(writer)
+				}
+				fmt.Fprintf(writer, "==> %s <==\n", file.Path)
+				_, err = writer.Write(file.Annotated())
+			}
+			if err != nil {
+				closeOutput()
+				return fmt.Errorf("failed to write annotations: %v", err)
+			}
+		}
+
+		if err := closeOutput(); err != nil {
+			return fmt.Errorf("failed to close output file: %v", err)
+		}
+
+		if outputFile != "" {
+			fmt.Fprintf(os.Stderr, "Annotations saved to %s\n", outputFile)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(annotateCmd)
+	annotateCmd.Flags().StringVar(&annotatePath, "path", "", "Path to IaC files (required)")
+	annotateCmd.Flags().BoolVar(&annotatePatch, "patch", false, "Write the annotations as a unified diff")
+	annotateCmd.Flags().StringVar(&outputFile, "output-file", "", "File to save the annotations to")
+	annotateCmd.Flags().StringVar(&usageFile, "usage-file", "", "Usage file with estimates for usage-based resources")
+	annotateCmd.MarkFlagRequired("path")
+}
@@ -0,0 +1,216 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/littleworks-inc/cloudcost/internal/controller"
+	"github.com/littleworks-inc/cloudcost/internal/parser/terraform"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/cache"
+	"github.com/littleworks-inc/cloudcost/internal/usage"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+var cacheWarmPath string
+var cacheClearExpired bool
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the pricing cache",
+	Long: `Manage the on-disk cache of pricing API responses.
+
+Pricing requests are cached under pricing.cache_dir (default: a cloudcost-cache
+directory in the system temp directory) for pricing.cache_ttl seconds, so
+identical requests are only sent once. The directory can be shared by parallel
+CI jobs. Set pricing.cache_ttl to 0 to disable the cache.`,
+}
+
+// cacheStatsCmd represents the cache stats command
+var cacheStatsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show the number and size of cached pricing responses",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		stats, err := pricingCache().Stats()
+		if err != nil {
+			return err
+		}
+
+		if outputFormat == "json" {
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(stats)
+		}
+
+		printCacheStats(stats)
+		return nil
+	},
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:   "clear",
+	Short: "Remove cached pricing responses",
+	Long: `Remove every cached pricing response, or only expired ones with --expired.
+
+Examples:
+  cloudcost cache clear
+  cloudcost cache clear --expired
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c := pricingCache()
+
+		var removed int
+		var err error
+		if cacheClearExpired {
+			removed, err = c.Prune()
+		} else {
+			removed, err = c.Clear()
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Removed %d cache entries from %s\n", removed, c.Dir())
+		return nil
+	},
+}
+
+// cacheWarmCmd represents the cache warm command
+var cacheWarmCmd = &cobra.Command{
+	Use:   "warm",
+	Short: "Fetch and cache the prices needed by IaC files",
+	Long: `Price every resource in the IaC files and cache the pricing responses,
+without writing a report. Run it once before starting parallel CI jobs that
+share the cache directory.
+
+Examples:
+  cloudcost cache warm --path ./terraform-project
+  cloudcost cache warm --path ./terraform-project --usage-file usage.yml
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Check if path exists
+		if _, err := os.Stat(cacheWarmPath); os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist: %s", cacheWarmPath)
+		}
+
+		c := pricingCache()
+		if c.TTL() <= 0 {
+			return fmt.Errorf("the pricing cache is disabled (pricing.cache_ttl is 0)")
+		}
+		if viper.GetString("pricing.index_dir") != "" || viper.GetString("pricing.replay_dir") != "" {
+			return fmt.Errorf("the pricing cache is not used with --pricing-index or --pricing-replay")
+		}
+
+		before, err := c.Stats()
+		if err != nil {
+			return err
+		}
+
+		// Create estimator
+		estimator := controller.NewEstimator()
+
+		// Register parsers
+		estimator.RegisterParser(terraform.NewParser())
+
+		// Register pricing clients
+		if err := registerPricingClients(estimator); err != nil {
+			return err
+		}
+
+		// Load usage estimates
+		if usageFile != "" {
+			usageData, err := usage.Load(usageFile)
+			if err != nil {
+				return err
+			}
+			estimator.Usage = usageData
+		}
+
+		report, err := estimator.Estimate(cacheWarmPath)
+		if err != nil {
+			return fmt.Errorf("estimation failed: %v", err)
+		}
+
+		after, err := c.Stats()
+		if err != nil {
+			return err
+		}
+
+		priced := 0
+		var failures []string
+		for _, resource := range report.Resources {
+			if resource.Unpriced == nil {
+				priced++
+			} else if resource.Unpriced.Reason == model.ReasonAPIError {
+				failures = append(failures, fmt.Sprintf("%s: %s", resource.ID, resource.Unpriced.Message))
+			}
+		}
+
+		fmt.Printf("Priced %d of %d resources\n", priced, len(report.Resources))
+		fmt.Printf("Cache entries: %d (%d new) in %s\n", after.Entries, max(after.Entries-before.Entries, 0), c.Dir())
+		for _, failure := range failures {
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", failure)
+		}
+		return nil
+	},
+}
+
+// pricingCache returns the cache configured by pricing.cache_dir and
+// pricing.cache_ttl
+func pricingCache() *cache.Cache {
+	ttl := time.Duration(viper.GetInt64("pricing.cache_ttl")) * time.Second
+	return cache.New(viper.GetString("pricing.cache_dir"), ttl)
+}
+
+// printCacheStats displays cache statistics as text
+func printCacheStats(stats cache.Stats) {
+	fmt.Printf("Cache directory: %s\n", stats.Dir)
+	if stats.TTLSeconds > 0 {
+		fmt.Printf("TTL:             %s\n", time.Duration(stats.TTLSeconds)*time.Second)
+	} else {
+		fmt.Printf("TTL:             disabled\n")
+	}
+	fmt.Printf("Entries:         %d (%d expired)\n", stats.Entries, stats.Expired)
+	fmt.Printf("Size:            %s\n", formatBytes(stats.Bytes))
+	if stats.Entries > 0 {
+		fmt.Printf("Oldest entry:    %s\n", stats.Oldest.Format(time.RFC3339))
+		fmt.Printf("Newest entry:    %s\n", stats.Newest.Format(time.RFC3339))
+	}
+}
+
+// formatBytes formats a size in bytes with a binary unit
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	for _, suffix := range []string{"KiB", "MiB", "GiB"} {
+		value /= unit
+		if value < unit || suffix == "GiB" {
+			return fmt.Sprintf("%.1f %s", value, suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheStatsCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+	cacheCmd.AddCommand(cacheWarmCmd)
+
+	cacheClearCmd.Flags().BoolVar(&cacheClearExpired, "expired", false, "Only remove expired entries")
+	cacheWarmCmd.Flags().StringVar(&cacheWarmPath, "path", "", "Path to IaC files (required)")
+	cacheWarmCmd.Flags().StringVar(&usageFile, "usage-file", "", "Usage file with estimates for usage-based resources")
+	cacheWarmCmd.MarkFlagRequired("path")
+
+	viper.SetDefault("pricing.cache_ttl", 3600)
+	viper.BindEnv("pricing.cache_ttl", "CLOUDCOST_CACHE_TTL")
+	viper.BindEnv("pricing.cache_dir", "CLOUDCOST_CACHE_DIR")
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/littleworks-inc/cloudcost/internal/controller"
+	"github.com/littleworks-inc/cloudcost/internal/parser/terraform"
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/spf13/cobra"
+)
+
+var coveragePath string
+var minCoverage float64
+
+// coverageCmd represents the coverage command
+var coverageCmd = &cobra.Command{
+	Use:   "coverage",
+	Short: "Report which resource types can be priced",
+	Long: `List every resource type found in IaC files and whether it is priced,
+free, usage-based or unsupported, with a coverage percentage.
+
+Use --min-coverage to fail (for example in CI) when coverage drops below a threshold.
+
+Examples:
+  cloudcost coverage --path ./terraform-project
+  cloudcost coverage --path ./terraform-project --min-coverage 90
+  cloudcost coverage --path ./terraform-project --output json
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Check if path exists
+		if _, err := os.Stat(coveragePath); os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist: %s", coveragePath)
+		}
+
+		if outputFormat != "text" && outputFormat != "json" {
+			return fmt.Errorf("unsupported output format %q (available: [json text])", outputFormat)
+		}
+
+		// Create estimator
+		estimator := controller.NewEstimator()
+
+		// Register parsers
+		estimator.RegisterParser(terraform.NewParser())
+
+		// Register pricing clients
+		if err := registerPricingClients(estimator); err != nil {
+			return err
+		}
+
+		report, err := estimator.Coverage(coveragePath)
+		if err != nil {
+			return err
+		}
+
+		switch outputFormat {
+		case "json":
+			encoder := json.NewEncoder(os.Stdout)
+			encoder.SetIndent("", "  ")
+			if err := encoder.Encode(report); err != nil {
+				return err
+			}
+		case "text":
+			printCoverage(report)
+		}
+
+		if report.Percent < minCoverage {
+			return fmt.Errorf("pricing coverage %.1f%% is below the minimum of %.1f%%", report.Percent, minCoverage)
+		}
+
+		return nil
+	},
+}
+
+// printCoverage displays a coverage report as text
+func printCoverage(report *controller.CoverageReport) {
+	fmt.Printf("\nPricing coverage:\n")
+	fmt.Printf("%-45s %-12s %s\n", "RESOURCE TYPE", "STATUS", "COUNT")
+	for _, coverage := range report.Types {
+		fmt.Printf("%-45s %-12s %d\n", coverage.ResourceType, coverage.Status, coverage.Count)
+	}
+
+	fmt.Printf("\nTotal Resources: %d\n", report.TotalResources)
+	for _, status := range []pricing.Coverage{
+		pricing.CoveragePriced,
+		pricing.CoverageUsageBased,
+		pricing.CoverageFree,
+		pricing.CoverageUnsupported,
+	} {
+		fmt.Printf("  %-12s %d\n", status+":", report.Counts[status])
+	}
+	fmt.Printf("Coverage: %.1f%%\n", report.Percent)
+}
+
+func init() {
+	rootCmd.AddCommand(coverageCmd)
+	coverageCmd.Flags().StringVar(&coveragePath, "path", "", "Path to IaC files (required)")
+	coverageCmd.Flags().Float64Var(&minCoverage, "min-coverage", 0, "Fail if coverage is below this percentage")
+	coverageCmd.MarkFlagRequired("path")
+}
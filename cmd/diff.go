@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"fmt"
+	"os"
 
+	"github.com/littleworks-inc/cloudcost/internal/controller"
+	"github.com/littleworks-inc/cloudcost/internal/parser/terraform"
+	"github.com/littleworks-inc/cloudcost/internal/usage"
 	"github.com/spf13/cobra"
 )
 
@@ -16,25 +20,57 @@ var diffCmd = &cobra.Command{
 	Long: `Compare estimated costs between current IaC files and a previous cost report.
 
 Examples:
+  cloudcost estimate --path ./terraform-project --output json --output-file previous-report.json
   cloudcost diff --path ./terraform-project --compare-to previous-report.json
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
-		fmt.Printf("Comparing costs for %s against %s\n", diffPath, compareTo)
+		// Check if path exists
+		if _, err := os.Stat(diffPath); os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist: %s", diffPath)
+		}
 
-		// This is where we'll add the logic to:
-		// 1. Parse current IaC files
-		// 2. Load previous report
-		// 3. Compare resources and costs
-		// 4. Generate diff report
+		fmt.Fprintf(os.Stderr, "Comparing costs for %s against %s\n", diffPath, compareTo)
 
-		return nil
+		// Create estimator
+		estimator := controller.NewEstimator()
+
+		// Register parsers
+		estimator.RegisterParser(terraform.NewParser())
+
+		// Register pricing clients
+		if err := registerPricingClients(estimator); err != nil {
+			return err
+		}
+
+		// Load budget and policy limits
+		estimator.Policy = loadPolicy(cmd)
+
+		// Load usage estimates
+		if usageFile != "" {
+			usageData, err := usage.Load(usageFile)
+			if err != nil {
+				return err
+			}
+			estimator.Usage = usageData
+		}
+
+		// Estimate current costs and compare them with the previous report
+		report, err := estimator.Compare(diffPath, compareTo)
+		if err != nil {
+			return fmt.Errorf("comparison failed: %v", err)
+		}
+
+		return writeReport(report, outputFile)
 	},
 }
 
 func init() {
 	rootCmd.AddCommand(diffCmd)
 	diffCmd.Flags().StringVar(&diffPath, "path", "", "Path to IaC files (required)")
-	diffCmd.Flags().StringVar(&compareTo, "compare-to", "", "Previous cost report to compare against (required)")
+	diffCmd.Flags().StringVar(&compareTo, "compare-to", "", "Previous JSON cost report to compare against (required)")
+	diffCmd.Flags().StringVar(&outputFile, "output-file", "", "File to save the report to")
+	diffCmd.Flags().StringVar(&usageFile, "usage-file", "", "Usage file with estimates for usage-based resources")
+	addPolicyFlags(diffCmd)
 	diffCmd.MarkFlagRequired("path")
 	diffCmd.MarkFlagRequired("compare-to")
 }
@@ -3,17 +3,18 @@ package cmd
 import (
 	"fmt"
 	"os"
-	"strings"
-	"time"
 
 	"github.com/littleworks-inc/cloudcost/internal/controller"
+	"github.com/littleworks-inc/cloudcost/internal/output"
 	"github.com/littleworks-inc/cloudcost/internal/parser/terraform"
-	"github.com/littleworks-inc/cloudcost/internal/pricing/aws"
+	"github.com/littleworks-inc/cloudcost/internal/usage"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
 	"github.com/spf13/cobra"
 )
 
 var estimatePath string
 var outputFile string
+var usageFile string
 
 // estimateCmd represents the estimate command
 var estimateCmd = &cobra.Command{
@@ -25,6 +26,8 @@ using real-time pricing data from cloud provider APIs.
 Examples:
   cloudcost estimate --path ./terraform-project
   cloudcost estimate --path ./ansible-playbooks --output json
+  cloudcost estimate --path ./terraform-project --output json --output-file report.json
+  cloudcost estimate --path ./terraform-project --usage-file usage.yml
 `,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		// Check if path exists
@@ -32,7 +35,7 @@ Examples:
 			return fmt.Errorf("path does not exist: %s", estimatePath)
 		}
 
-		fmt.Printf("Estimating costs for IaC files in: %s\n", estimatePath)
+		fmt.Fprintf(os.Stderr, "Estimating costs for IaC files in: %s\n", estimatePath)
 
 		// Create estimator
 		estimator := controller.NewEstimator()
@@ -41,7 +44,26 @@ Examples:
 		estimator.RegisterParser(terraform.NewParser())
 
 		// Register pricing clients
-		estimator.RegisterPricingClient("aws", aws.NewClient())
+		if err := registerPricingClients(estimator); err != nil {
+			return err
+		}
+
+		// Load budget and policy limits
+		estimator.Policy = loadPolicy(cmd)
+
+		// Load usage estimates
+		if usageFile != "" {
+			usageData, err := usage.Load(usageFile)
+			if err != nil {
+				return err
+			}
+			estimator.Usage = usageData
+		}
+
+		// Write resources as they are priced instead of waiting for the whole report
+		if outputFormat == "ndjson" {
+			return streamEstimate(estimator, estimatePath, outputFile)
+		}
 
 		// Perform estimation
 		report, err := estimator.Estimate(estimatePath)
@@ -49,53 +71,63 @@ Examples:
 			return fmt.Errorf("estimation failed: %v", err)
 		}
 
-		// Set report timestamp
-		if report != nil {
-			report.Timestamp = time.Now()
-
-			// Display summary
-			fmt.Printf("\nEstimation complete:\n")
-			fmt.Printf("Total Resources: %d\n", len(report.Resources))
-			fmt.Printf("Estimated Monthly Cost: $%.2f\n", report.TotalMonthly)
-			fmt.Printf("Estimated Yearly Cost: $%.2f\n", report.TotalYearly)
-
-			// Display resource details
-			fmt.Printf("\nResource Details:\n")
-			for _, resource := range report.Resources {
-				fmt.Printf("- %s (%s): $%.4f/hour, $%.2f/month",
-					resource.Name, resource.Size, resource.HourlyPrice, resource.MonthlyPrice)
+		if report == nil {
+			return fmt.Errorf("no resources found or no pricing data available")
+		}
 
-				// Display pricing source or error if available
-				if resource.PricingDetails != nil && strings.HasPrefix(resource.PricingDetails.PricingSource, "Error:") {
-					fmt.Printf(" [%s]", resource.PricingDetails.PricingSource)
-				}
+		addCredentialsWarning(report)
 
-				fmt.Println()
-			}
+		return writeReport(report, outputFile)
+	},
+}
 
-			// Display warnings about missing credentials if all prices are zero
-			if report.TotalMonthly == 0 && len(report.Resources) > 0 {
-				fmt.Printf("\nWARNING: All resource prices are $0.00. This may be because:\n")
-				fmt.Printf("- AWS credentials are not configured\n")
-				fmt.Printf("- The AWS Pricing API is not accessible\n")
-				fmt.Printf("- The resource types are not supported for pricing\n")
-
-				fmt.Printf("\nTo configure AWS credentials:\n")
-				fmt.Printf("1. Install the AWS CLI: https://aws.amazon.com/cli/\n")
-				fmt.Printf("2. Run 'aws configure' and provide your AWS access key, secret key, and region\n")
-				fmt.Printf("3. Or set the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_REGION environment variables\n")
-			}
-		} else {
-			fmt.Printf("\nNo resources found or no pricing data available.\n")
-		}
+// streamEstimate writes each resource as NDJSON as soon as it is priced,
+// followed by a summary line
+func streamEstimate(estimator *controller.Estimator, path, filename string) error {
+	writer, closeOutput, err := createOutput(filename)
+	if err != nil {
+		return err
+	}
+
+	ndjson := output.NewNDJSONWriter(writer)
+	report, err := estimator.EstimateStream(path, ndjson.WriteResource)
+	if err != nil {
+		closeOutput()
+		return fmt.Errorf("estimation failed: %v", err)
+	}
+
+	addCredentialsWarning(report)
+
+	if err := ndjson.WriteSummary(report); err != nil {
+		closeOutput()
+		return fmt.Errorf("failed to write summary: %v", err)
+	}
+
+	if err := closeOutput(); err != nil {
+		return fmt.Errorf("failed to close output file: %v", err)
+	}
+
+	if filename != "" {
+		fmt.Fprintf(os.Stderr, "Report saved to %s\n", filename)
+	}
+
+	return nil
+}
 
-		return nil
-	},
+// addCredentialsWarning warns about missing credentials if all prices are zero
+// because pricing requests failed
+func addCredentialsWarning(report *model.Report) {
+	if report.TotalMonthly == 0 && len(report.Errors) > 0 {
+		report.AddWarning("All resource prices are $0.00. AWS credentials may not be configured or the AWS Pricing API " +
+			"may not be accessible. Run 'aws configure' or set AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_REGION.")
+	}
 }
 
 func init() {
 	rootCmd.AddCommand(estimateCmd)
 	estimateCmd.Flags().StringVar(&estimatePath, "path", "", "Path to IaC files (required)")
 	estimateCmd.Flags().StringVar(&outputFile, "output-file", "", "File to save the report to")
+	estimateCmd.Flags().StringVar(&usageFile, "usage-file", "", "Usage file with estimates for usage-based resources")
+	addPolicyFlags(estimateCmd)
 	estimateCmd.MarkFlagRequired("path")
 }
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/littleworks-inc/cloudcost/internal/output"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// newFormatterRegistry creates a registry with all available report formatters
+func newFormatterRegistry() *output.FormatterRegistry {
+	registry := output.NewFormatterRegistry()
+	registry.RegisterFormatter(output.NewTextFormatter(templateFile))
+	registry.RegisterFormatter(output.NewJSONFormatter())
+	registry.RegisterFormatter(output.NewCSVFormatter())
+	registry.RegisterFormatter(output.NewHTMLFormatter(templateFile))
+	registry.RegisterFormatter(output.NewMarkdownFormatter())
+	registry.RegisterFormatter(output.NewXLSXFormatter())
+	registry.RegisterFormatter(output.NewFOCUSFormatter())
+	registry.RegisterFormatter(output.NewSARIFFormatter())
+	registry.RegisterFormatter(output.NewJUnitFormatter())
+	registry.RegisterFormatter(output.NewNDJSONFormatter())
+	return registry
+}
+
+// writeReport formats a report in the selected output format and writes it to
+// the output file, or to stdout when no file is given
+func writeReport(report *model.Report, filename string) error {
+	registry := newFormatterRegistry()
+
+	formatter, ok := registry.GetFormatter(outputFormat)
+	if !ok {
+		names := make([]string, 0, len(registry.Formatters))
+		for name := range registry.Formatters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unsupported output format %q (available: %v)", outputFormat, names)
+	}
+
+	if templateFile != "" {
+		switch formatter.(type) {
+		case *output.TextFormatter, *output.HTMLFormatter:
+		default:
+			return fmt.Errorf("output format %q does not use templates", outputFormat)
+		}
+	}
+
+	writer, closeOutput, err := createOutput(filename)
+	if err != nil {
+		return err
+	}
+
+	if err := formatter.Format(report, writer); err != nil {
+		closeOutput()
+		return fmt.Errorf("failed to format report: %v", err)
+	}
+
+	// Writes to a file may only fail when it is closed
+	if err := closeOutput(); err != nil {
+		return fmt.Errorf("failed to close output file: %v", err)
+	}
+
+	if filename != "" {
+		fmt.Fprintf(os.Stderr, "Report saved to %s\n", filename)
+	}
+
+	return nil
+}
+
+// createOutput creates the output file, or returns stdout when no file is given
+func createOutput(filename string) (io.Writer, func() error, error) {
+	if filename == "" {
+		return os.Stdout, func() error { return nil }, nil
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create output file: %v", err)
+	}
+	return file, file.Close, nil
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/littleworks-inc/cloudcost/internal/policy"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// addPolicyFlags adds the budget flags shared by commands that price resources
+func addPolicyFlags(cmd *cobra.Command) {
+	cmd.Flags().Float64("budget", 0, "Monthly budget checked against the total cost (0 disables the check)")
+	cmd.Flags().Float64("budget-increase", 0, "Budget for the monthly cost increase in diff reports (0 disables the check)")
+}
+
+// loadPolicy reads budget and policy limits from the config file, with the
+// command's budget flags taking precedence
+func loadPolicy(cmd *cobra.Command) policy.Config {
+	viper.BindPFlag("policy.monthly_budget", cmd.Flags().Lookup("budget"))
+	viper.BindPFlag("policy.monthly_increase_budget", cmd.Flags().Lookup("budget-increase"))
+
+	return policy.Config{
+		MonthlyBudget:              viper.GetFloat64("policy.monthly_budget"),
+		MonthlyIncreaseBudget:      viper.GetFloat64("policy.monthly_increase_budget"),
+		MaxResourceMonthly:         viper.GetFloat64("policy.max_resource_monthly"),
+		MaxResourceIncrease:        viper.GetFloat64("policy.max_resource_increase"),
+		MaxResourceIncreasePercent: viper.GetFloat64("policy.max_resource_increase_percent"),
+	}
+}
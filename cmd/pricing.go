@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/littleworks-inc/cloudcost/internal/controller"
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/aws"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/aws/offers"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// pricingCmd represents the pricing command
+var pricingCmd = &cobra.Command{
+	Use:   "pricing",
+	Short: "Manage offline pricing data",
+	Long: `Manage the local pricing index used to estimate costs without AWS
+credentials or network access.`,
+}
+
+// pricingImportCmd represents the pricing import command
+var pricingImportCmd = &cobra.Command{
+	Use:   "import FILE_OR_DIR...",
+	Short: "Build a pricing index from AWS bulk offer files",
+	Long: `Read AWS Price List bulk offer files in JSON or CSV format and write a
+compact index to the --pricing-index directory (or pricing.index_dir).
+Directories are searched for .json and .csv files. Offer files for several
+regions of a service are merged; importing a service again replaces it.
+
+Commands given the same --pricing-index then price resources from the index
+instead of the AWS Pricing API. Only on-demand prices are imported.
+
+Offer files can be downloaded from
+https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/index.json, for example:
+  curl -O https://pricing.us-east-1.amazonaws.com/offers/v1.0/aws/AmazonEC2/current/us-east-1/index.json
+
+Examples:
+  cloudcost pricing import ./offers --pricing-index ./pricing-index
+  cloudcost pricing import AmazonEC2-us-east-1.csv AmazonS3.json --pricing-index ./pricing-index
+  cloudcost estimate --path ./terraform-project --pricing-index ./pricing-index
+`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		indexDir := viper.GetString("pricing.index_dir")
+		if indexDir == "" {
+			return fmt.Errorf("no index directory given: use --pricing-index or set pricing.index_dir")
+		}
+
+		var paths []string
+		for _, arg := range args {
+			info, err := os.Stat(arg)
+			if err != nil {
+				return fmt.Errorf("path does not exist: %s", arg)
+			}
+			if !info.IsDir() {
+				paths = append(paths, arg)
+				continue
+			}
+			found, err := offers.FindOfferFiles(arg)
+			if err != nil {
+				return err
+			}
+			paths = append(paths, found...)
+		}
+
+		fmt.Fprintf(os.Stderr, "Importing %d offer files into %s\n", len(paths), indexDir)
+
+		summary, err := offers.Import(paths, indexDir)
+		if err != nil {
+			return err
+		}
+
+		for _, path := range summary.Skipped {
+			fmt.Fprintf(os.Stderr, "Skipped %s: not an offer file\n", path)
+		}
+		if len(summary.Services) == 0 {
+			return fmt.Errorf("no offer files found")
+		}
+		for _, result := range summary.Services {
+			fmt.Printf("%-30s %8d products from %d files\n", result.ServiceCode, result.Products, len(result.Files))
+		}
+		return nil
+	},
+}
+
+// registerPricingClients registers the pricing clients configured by the
+// pricing settings, along with the number of resources priced at once
+func registerPricingClients(estimator *controller.Estimator) error {
+	awsClient, err := newAWSClient()
+	if err != nil {
+		return err
+	}
+	estimator.RegisterPricingClient("aws", awsClient)
+
+	if workers := viper.GetInt("pricing.concurrency"); workers > 0 {
+		estimator.Calculator.Workers = workers
+	}
+	return nil
+}
+
+// newAWSClient creates the AWS pricing client configured by the pricing
+// settings: recorded responses, the offer file index, or the Pricing API
+// through the pricing cache
+func newAWSClient() (pricing.Client, error) {
+	indexDir := viper.GetString("pricing.index_dir")
+	recordDir := viper.GetString("pricing.record_dir")
+	replayDir := viper.GetString("pricing.replay_dir")
+
+	if replayDir != "" && recordDir != "" {
+		return nil, fmt.Errorf("--pricing-record and --pricing-replay cannot be used together")
+	}
+	if replayDir != "" && indexDir != "" {
+		return nil, fmt.Errorf("--pricing-replay and --pricing-index cannot be used together")
+	}
+
+	var options []aws.Option
+	switch {
+	case replayDir != "":
+		options = append(options, aws.WithReplay(replayDir))
+	case indexDir != "":
+		options = append(options, aws.WithOfflineIndex(indexDir))
+	default:
+		if endpoint := viper.GetString("pricing.aws_endpoint"); endpoint != "" {
+			options = append(options, aws.WithEndpoint(endpoint))
+		}
+		if rate := viper.GetFloat64("pricing.aws_rate_limit"); rate > 0 {
+			options = append(options, aws.WithRateLimit(rate))
+		}
+		if c := pricingCache(); c.TTL() > 0 {
+			options = append(options, aws.WithCache(c))
+		}
+	}
+	if recordDir != "" {
+		options = append(options, aws.WithRecording(recordDir))
+	}
+
+	platforms := viper.GetStringMapString("pricing.aws_ami_platforms")
+	for ami, platform := range platforms {
+		if !slices.Contains(aws.Platforms(), platform) {
+			return nil, fmt.Errorf("unknown platform %q for %s in pricing.aws_ami_platforms (use one of: %s)",
+				platform, ami, strings.Join(aws.Platforms(), ", "))
+		}
+	}
+	if len(platforms) > 0 {
+		options = append(options, aws.WithAMIPlatforms(platforms))
+	}
+
+	return aws.NewClient(options...), nil
+}
+
+func init() {
+	rootCmd.AddCommand(pricingCmd)
+	pricingCmd.AddCommand(pricingImportCmd)
+
+	viper.BindEnv("pricing.aws_endpoint", "CLOUDCOST_AWS_PRICING_ENDPOINT")
+}
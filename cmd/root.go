@@ -10,6 +10,7 @@ import (
 
 var cfgFile string
 var outputFormat string
+var templateFile string
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
@@ -37,9 +38,16 @@ func init() {
 
 	// Global flags
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.cloudcost.yaml)")
-	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Output format (text, json, csv, html)")
+	rootCmd.PersistentFlags().StringVar(&outputFormat, "output", "text", "Output format (text, json, ndjson, csv, markdown, html, xlsx, focus, sarif, junit)")
+	rootCmd.PersistentFlags().StringVar(&templateFile, "template", "", "Template file overriding the built-in text or html report template")
+	rootCmd.PersistentFlags().String("pricing-index", "", "Directory of AWS offer files imported with 'pricing import', used instead of the Pricing API")
+	rootCmd.PersistentFlags().String("pricing-record", "", "Directory to record AWS Pricing API requests and responses to")
+	rootCmd.PersistentFlags().String("pricing-replay", "", "Directory of recorded AWS Pricing API responses to use instead of the API")
 
 	viper.BindPFlag("output", rootCmd.PersistentFlags().Lookup("output"))
+	viper.BindPFlag("pricing.index_dir", rootCmd.PersistentFlags().Lookup("pricing-index"))
+	viper.BindPFlag("pricing.record_dir", rootCmd.PersistentFlags().Lookup("pricing-record"))
+	viper.BindPFlag("pricing.replay_dir", rootCmd.PersistentFlags().Lookup("pricing-replay"))
 }
 
 // initConfig reads in config file and ENV variables if set.
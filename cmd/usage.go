@@ -0,0 +1,103 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/littleworks-inc/cloudcost/internal/controller"
+	"github.com/littleworks-inc/cloudcost/internal/parser/terraform"
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/aws"
+	"github.com/littleworks-inc/cloudcost/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var usagePath string
+var usageOutputFile string
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Manage usage estimates for usage-based resources",
+	Long: `Manage the usage file that supplies estimates such as request counts,
+storage and data transfer for resources that are billed by usage.`,
+}
+
+// usageInitCmd represents the usage init command
+var usageInitCmd = &cobra.Command{
+	Use:   "init",
+	Short: "Generate a usage file skeleton from IaC files",
+	Long: `Parse Infrastructure-as-Code files and write a commented usage file listing
+every usage key understood for each usage-based resource.
+
+Values already present in an existing usage file are never overwritten; only
+missing keys are added.
+
+Examples:
+  cloudcost usage init --path ./terraform-project
+  cloudcost usage init --path ./terraform-project --usage-file usage.yml
+`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// Check if path exists
+		if _, err := os.Stat(usagePath); os.IsNotExist(err) {
+			return fmt.Errorf("path does not exist: %s", usagePath)
+		}
+
+		// Create estimator
+		estimator := controller.NewEstimator()
+
+		// Register parsers
+		estimator.RegisterParser(terraform.NewParser())
+
+		// Register pricing clients
+		estimator.RegisterPricingClient("aws", aws.NewClient())
+
+		resources, err := estimator.Parse(usagePath)
+		if err != nil {
+			return err
+		}
+
+		// Collect the usage keys for every usage-based resource
+		var entries []usage.Entry
+		for _, resource := range resources {
+			describer, ok := estimator.PricingClients[resource.Provider].(pricing.UsageDescriber)
+			if !ok {
+				continue
+			}
+
+			keys := describer.UsageKeys(resource.ResourceType)
+			if len(keys) == 0 {
+				continue
+			}
+
+			entries = append(entries, usage.Entry{Address: resource.ID, Keys: keys})
+		}
+
+		// Merge into the existing usage file, if any
+		existing, err := os.ReadFile(usageOutputFile)
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to read usage file %s: %v", usageOutputFile, err)
+		}
+
+		merged, added, err := usage.Merge(existing, entries)
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(usageOutputFile, merged, 0644); err != nil {
+			return fmt.Errorf("failed to write usage file %s: %v", usageOutputFile, err)
+		}
+
+		fmt.Printf("Wrote %s: %d usage-based resources, %d keys added\n", usageOutputFile, len(entries), added)
+
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+	usageCmd.AddCommand(usageInitCmd)
+	usageInitCmd.Flags().StringVar(&usagePath, "path", "", "Path to IaC files (required)")
+	usageInitCmd.Flags().StringVar(&usageOutputFile, "usage-file", "usage.yml", "Usage file to create or update")
+	usageInitCmd.MarkFlagRequired("path")
+}
@@ -0,0 +1,10 @@
+// Package configs holds the default configuration and report templates
+// compiled into the binary.
+package configs
+
+import "embed"
+
+// Templates contains the default report templates under templates/
+//
+//go:embed templates/*.tmpl
+var Templates embed.FS
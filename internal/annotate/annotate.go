@@ -0,0 +1,299 @@
+package annotate
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// Marker starts every comment written by annotate, so annotations from a
+// previous run are replaced rather than repeated
+const Marker = "# cloudcost:"
+
+// contextLines is the number of unchanged lines around each patch hunk
+const contextLines = 3
+
+// File is an IaC file with cost annotations
+type File struct {
+	Path  string   // Path as recorded by the parser
+	lines []string // Original lines, without line endings
+	edits []edit   // Annotations, sorted by position
+	eol   bool     // Whether the original ends with a newline
+}
+
+// edit replaces the original lines [start, end) with new lines
+type edit struct {
+	start int
+	end   int
+	lines []string
+}
+
+// Annotate reads the files defining the resources and adds a monthly cost
+// comment above each resource block and a total at the end of each module,
+// the directory holding its files. Resources without a source range are
+// skipped.
+func Annotate(resources []model.Resource) ([]*File, error) {
+	byPath := make(map[string]*File)
+	var paths []string
+
+	// Module totals are written at the end of the module's last file
+	moduleTotals := make(map[string]float64)
+	moduleCounts := make(map[string]int)
+	moduleFiles := make(map[string]string)
+
+	for _, resource := range resources {
+		if resource.Source == nil {
+			continue
+		}
+
+		file, ok := byPath[resource.Source.Filename]
+		if !ok {
+			var err error
+			file, err = readFile(resource.Source.Filename)
+			if err != nil {
+				return nil, err
+			}
+			byPath[file.Path] = file
+			paths = append(paths, file.Path)
+		}
+
+		header := resource.Source.StartLine - 1
+		if header < 0 || header >= len(file.lines) {
+			return nil, fmt.Errorf("%s: line %d is outside the file", file.Path, resource.Source.StartLine)
+		}
+
+		// Replace annotations left directly above the header by an earlier run
+		start := header
+		for start > 0 && isAnnotation(file.lines[start-1]) {
+			start--
+		}
+		indent := file.lines[header][:len(file.lines[header])-len(strings.TrimLeft(file.lines[header], " \t"))]
+		file.edits = append(file.edits, edit{
+			start: start,
+			end:   header,
+			lines: []string{indent + Marker + " " + resourceComment(resource)},
+		})
+
+		module := resource.Module()
+		moduleTotals[module] += resource.TotalMonthly
+		moduleCounts[module]++
+		if file.Path > moduleFiles[module] {
+			moduleFiles[module] = file.Path
+		}
+	}
+
+	for module, path := range moduleFiles {
+		file := byPath[path]
+
+		// Replace a total left at the end of the file by an earlier run
+		end := len(file.lines)
+		start := end
+		for start > 0 && isAnnotation(file.lines[start-1]) {
+			start--
+		}
+		lines := []string{}
+		if start == 0 || strings.TrimSpace(file.lines[start-1]) != "" {
+			lines = append(lines, "")
+		}
+		lines = append(lines, fmt.Sprintf("%s total for module %q: $%.2f/month (%d resources)",
+			Marker, module, moduleTotals[module], moduleCounts[module]))
+		file.edits = append(file.edits, edit{start: start, end: end, lines: lines})
+	}
+
+	sort.Strings(paths)
+	files := make([]*File, 0, len(paths))
+	for _, path := range paths {
+		file := byPath[path]
+		sort.SliceStable(file.edits, func(i, j int) bool {
+			return file.edits[i].start < file.edits[j].start
+		})
+
+		// Drop annotations that are already up to date
+		edits := file.edits[:0]
+		for _, e := range file.edits {
+			if !slices.Equal(file.lines[e.start:e.end], e.lines) {
+				edits = append(edits, e)
+			}
+		}
+		file.edits = edits
+
+		// Appending to a file without a final newline changes its last line
+		if n := len(file.edits); n > 0 && !file.eol && len(file.lines) > 0 {
+			last := &file.edits[n-1]
+			if last.end == len(file.lines) && last.start == last.end {
+				last.start--
+				last.lines = append([]string{file.lines[last.start]}, last.lines...)
+			}
+		}
+
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+// Annotated returns the file content with the annotations applied
+func (f *File) Annotated() []byte {
+	lines := f.apply()
+	var b bytes.Buffer
+	for i, line := range lines {
+		b.WriteString(line)
+		if i < len(lines)-1 || f.endsWithNewline() {
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+// WritePatch writes the annotations as a unified diff that applies with
+// `git apply` or `patch -p1` from the working directory
+func (f *File) WritePatch(w io.Writer) error {
+	path := relativePath(f.Path)
+
+	// Group edits whose context would overlap into hunks
+	var hunks [][]edit
+	for _, e := range f.edits {
+		if n := len(hunks); n > 0 {
+			last := hunks[n-1][len(hunks[n-1])-1]
+			if e.start-last.end <= 2*contextLines {
+				hunks[n-1] = append(hunks[n-1], e)
+				continue
+			}
+		}
+		hunks = append(hunks, []edit{e})
+	}
+	if len(hunks) == 0 {
+		return nil
+	}
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "--- a/%s\n+++ b/%s\n", path, path)
+
+	// Offset of new line numbers caused by earlier hunks
+	offset := 0
+	for _, hunk := range hunks {
+		oldStart := max(hunk[0].start-contextLines, 0)
+		oldEnd := min(hunk[len(hunk)-1].end+contextLines, len(f.lines))
+
+		var body bytes.Buffer
+		original := func(prefix string, index int) {
+			body.WriteString(prefix + f.lines[index] + "\n")
+			if index == len(f.lines)-1 && !f.eol {
+				body.WriteString("\\ No newline at end of file\n")
+			}
+		}
+
+		added := 0
+		position := oldStart
+		for _, e := range hunk {
+			for ; position < e.start; position++ {
+				original(" ", position)
+			}
+			for ; position < e.end; position++ {
+				original("-", position)
+			}
+			for _, line := range e.lines {
+				body.WriteString("+" + line + "\n")
+			}
+			added += len(e.lines) - (e.end - e.start)
+		}
+		for ; position < oldEnd; position++ {
+			original(" ", position)
+		}
+
+		oldCount := oldEnd - oldStart
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(oldStart+offset, oldCount+added))
+		b.Write(body.Bytes())
+		offset += added
+	}
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+// endsWithNewline reports whether the annotated file ends with a newline,
+// which it does unless the original did not and its end is unchanged
+func (f *File) endsWithNewline() bool {
+	if f.eol {
+		return true
+	}
+	n := len(f.edits)
+	return n > 0 && f.edits[n-1].end == len(f.lines)
+}
+
+// apply returns the lines of the annotated file
+func (f *File) apply() []string {
+	var lines []string
+	position := 0
+	for _, e := range f.edits {
+		lines = append(lines, f.lines[position:e.start]...)
+		lines = append(lines, e.lines...)
+		position = e.end
+	}
+	return append(lines, f.lines[position:]...)
+}
+
+// readFile reads a file into lines
+func readFile(path string) (*File, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", path, err)
+	}
+
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	file := &File{Path: path, eol: strings.HasSuffix(text, "\n")}
+	text = strings.TrimSuffix(text, "\n")
+	if text != "" {
+		file.lines = strings.Split(text, "\n")
+	}
+	return file, nil
+}
+
+// resourceComment describes the monthly cost of a resource
+func resourceComment(resource model.Resource) string {
+	if resource.Unpriced != nil {
+		return "not priced (" + resource.Unpriced.Reason.Description() + ")"
+	}
+	if resource.PricingDetails != nil && resource.PricingDetails.PricingSource == pricing.FreeSource {
+		return "free"
+	}
+	if resource.Quantity > 1 {
+		return fmt.Sprintf("$%.2f/month (%d x $%.2f)", resource.TotalMonthly, resource.Quantity, resource.MonthlyPrice)
+	}
+	return fmt.Sprintf("$%.2f/month", resource.TotalMonthly)
+}
+
+// isAnnotation reports whether a line is a comment written by annotate
+func isAnnotation(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), Marker)
+}
+
+// hunkRange formats the start and length of a hunk side; empty sides refer
+// to the line before the change
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// relativePath converts a path into a slash-separated path relative to the
+// working directory
+func relativePath(path string) string {
+	if filepath.IsAbs(path) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, path); err == nil {
+				path = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(path))
+}
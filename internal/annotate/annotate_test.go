@@ -0,0 +1,146 @@
+package annotate
+
+import (
+	"bytes"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+func TestWritePatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		lines   []int     // Header line of each resource
+		costs   []float64 // Monthly cost of each resource
+		hunks   []string  // Expected hunk headers
+	}{
+		{
+			name: "adjacent resources share a hunk",
+			content: `resource "aws_instance" "a" {
+}
+resource "aws_instance" "b" {
+}
+`,
+			lines: []int{1, 3},
+			costs: []float64{1, 2},
+			hunks: []string{"@@ -1,4 +1,8 @@"},
+		},
+		{
+			name: "overlapping context merges hunks",
+			content: `resource "aws_instance" "a" {
+  instance_type = "t3.micro"
+  tags = {}
+  monitoring    = true
+}
+
+resource "aws_instance" "b" {
+  instance_type = "t3.micro"
+}
+`,
+			lines: []int{1, 7},
+			costs: []float64{1, 2},
+			hunks: []string{"@@ -1,9 +1,13 @@"},
+		},
+		{
+			name: "distant resources get separate hunks",
+			content: `resource "aws_instance" "a" {
+  a = 1
+  b = 2
+  c = 3
+  d = 4
+  e = 5
+  f = 6
+  g = 7
+}
+resource "aws_instance" "b" {
+}
+`,
+			lines: []int{1, 10},
+			costs: []float64{1, 2},
+			hunks: []string{"@@ -1,3 +1,4 @@", "@@ -7,5 +8,8 @@"},
+		},
+		{
+			name: "annotations from an earlier run are replaced",
+			content: `# cloudcost: $5.00/month
+resource "aws_instance" "a" {
+}
+
+# cloudcost: total for module ".": $5.00/month (1 resources)
+`,
+			lines: []int{2},
+			costs: []float64{1},
+			hunks: []string{"@@ -1,5 +1,5 @@"},
+		},
+		{
+			name:    "file without a final newline",
+			content: "resource \"aws_instance\" \"a\" {\n}",
+			lines:   []int{1},
+			costs:   []float64{1},
+			hunks:   []string{"@@ -1,2 +1,5 @@"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Chdir(t.TempDir())
+			if err := os.WriteFile("main.tf", []byte(test.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			var resources []model.Resource
+			for i, line := range test.lines {
+				resources = append(resources, model.Resource{
+					ID:           "aws_instance.r" + string(rune('a'+i)),
+					Quantity:     1,
+					TotalMonthly: test.costs[i],
+					Source:       &model.SourceRange{Filename: "main.tf", StartLine: line},
+				})
+			}
+
+			files, err := Annotate(resources)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(files) != 1 {
+				t.Fatalf("got %d files, want 1", len(files))
+			}
+
+			var patch bytes.Buffer
+			if err := files[0].WritePatch(&patch); err != nil {
+				t.Fatal(err)
+			}
+
+			hunks := regexp.MustCompile(`(?m)^@@ .* @@$`).FindAllString(patch.String(), -1)
+			if !slices.Equal(hunks, test.hunks) {
+				t.Errorf("hunks = %q, want %q\n%s", hunks, test.hunks, patch.String())
+			}
+
+			// The patch must apply and give the annotated file
+			if _, err := exec.LookPath("git"); err != nil {
+				t.Skip("git not found")
+			}
+			if err := os.WriteFile("annotations.patch", patch.Bytes(), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			for _, args := range [][]string{{"apply", "--check", "annotations.patch"}, {"apply", "annotations.patch"}} {
+				if output, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+					t.Fatalf("git %s: %v\n%s\n%s", strings.Join(args, " "), err, output, patch.String())
+				}
+			}
+
+			applied, err := os.ReadFile("main.tf")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := files[0].Annotated(); !bytes.Equal(applied, want) {
+				t.Errorf("patched file:\n%s\nwant:\n%s", applied, want)
+			}
+		})
+	}
+}
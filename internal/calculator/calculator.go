@@ -1,19 +1,27 @@
 package calculator
 
 import (
+	"fmt"
+	"sync"
+
 	"github.com/littleworks-inc/cloudcost/internal/pricing"
 	"github.com/littleworks-inc/cloudcost/pkg/model"
 )
 
+// DefaultWorkers is the default number of resources priced at the same time
+const DefaultWorkers = 8
+
 // Calculator calculates costs for cloud resources
 type Calculator struct {
 	PricingClients map[string]pricing.Client
+	Workers        int // Resources priced at the same time
 }
 
 // NewCalculator creates a new calculator with pricing clients
 func NewCalculator() *Calculator {
 	return &Calculator{
 		PricingClients: make(map[string]pricing.Client),
+		Workers:        DefaultWorkers,
 	}
 }
 
@@ -25,40 +33,154 @@ func (c *Calculator) RegisterPricingClient(provider string, client pricing.Clien
 // CalculateCosts calculates costs for all resources
 func (c *Calculator) CalculateCosts(resources []model.Resource) (*model.Report, error) {
 	// Initialize report
-	report := &model.Report{
-		Resources:      resources,
-		ByProvider:     make(map[string]float64),
-		ByResourceType: make(map[string]float64),
-		ByRegion:       make(map[string]float64),
-	}
+	report := model.NewReport()
+	report.Resources = resources
 
 	// Calculate costs for each resource
-	for i := range resources {
-		resource := &resources[i]
+	failed := 0
+	c.priceAll(resources, func(resource *model.Resource) error {
+		failed += apiFailures(resource)
+		reportWarnings(report, resource)
+		return nil
+	})
+	reportFailures(report, failed)
+
+	// Apply quantities and calculate totals and breakdowns
+	report.Summarize()
+
+	return report, nil
+}
+
+// CalculateCostsStream prices resources, passing each to emit in order as soon
+// as it is priced. The returned report has totals, breakdowns, errors and
+// warnings but no resources.
+func (c *Calculator) CalculateCostsStream(resources []model.Resource, emit func(*model.Resource) error) (*model.Report, error) {
+	report := model.NewReport()
+
+	failed := 0
+	err := c.priceAll(resources, func(resource *model.Resource) error {
+		failed += apiFailures(resource)
+		reportWarnings(report, resource)
+		report.AddToTotals(resource)
+		return emit(resource)
+	})
+	if err != nil {
+		return nil, err
+	}
+	reportFailures(report, failed)
+
+	return report, nil
+}
+
+// priceAll prices resources with a pool of workers and calls done for each
+// resource in input order, as soon as it and every resource before it are
+// priced. Pricing stops early if done returns an error.
+func (c *Calculator) priceAll(resources []model.Resource, done func(*model.Resource) error) error {
+	// One buffered channel per resource signals that it is priced
+	priced := make([]chan struct{}, len(resources))
+	for i := range priced {
+		priced[i] = make(chan struct{}, 1)
+	}
+
+	jobs := make(chan int)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
 
-		// Get client for this provider
-		client, ok := c.PricingClients[resource.Provider]
-		if !ok {
-			// Skip resources with no pricing client
-			continue
+	for w := 0; w < max(c.Workers, 1); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				c.priceResource(&resources[i])
+				priced[i] <- struct{}{}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		for i := range resources {
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			}
 		}
+	}()
+
+	// Wait for the workers so no resource is written after returning
+	defer wg.Wait()
+	defer close(stop)
 
-		// Get pricing data
-		if err := client.GetPrice(resource); err != nil {
-			// Log error and continue
-			continue
+	for i := range resources {
+		<-priced[i]
+		if err := done(&resources[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// priceResource prices a single resource, recording why when it cannot be
+// priced. It only changes the resource, so resources can be priced
+// concurrently.
+func (c *Calculator) priceResource(resource *model.Resource) {
+	// Get client for this provider
+	client, ok := c.PricingClients[resource.Provider]
+	if !ok {
+		// Resources from providers that never charge need no client
+		if pricing.IsFree(resource.Provider, resource.ResourceType) {
+			pricing.SetFree(resource)
+			return
 		}
 
-		// Add to totals
-		report.TotalHourly += resource.HourlyPrice
-		report.TotalMonthly += resource.MonthlyPrice
-		report.TotalYearly += resource.YearlyPrice
+		// Record resources with no pricing client as unsupported
+		markUnpriced(resource, model.ReasonUnsupportedType,
+			fmt.Sprintf("no pricing client for provider %q", resource.Provider))
+		return
+	}
 
-		// Add to breakdowns
-		report.ByProvider[resource.Provider] += resource.MonthlyPrice
-		report.ByResourceType[resource.ResourceType] += resource.MonthlyPrice
-		report.ByRegion[resource.Region] += resource.MonthlyPrice
+	// Get pricing data
+	if err := client.GetPrice(resource); err != nil {
+		// Record the reason and continue
+		markUnpriced(resource, pricing.ReasonFor(err), err.Error())
 	}
+}
 
-	return report, nil
+// markUnpriced records why a resource could not be priced, dropping any price
+// set before pricing failed
+func markUnpriced(resource *model.Resource, reason model.UnpricedReason, message string) {
+	resource.HourlyPrice = 0
+	resource.MonthlyPrice = 0
+	resource.YearlyPrice = 0
+	resource.PricingDetails = nil
+	resource.Unpriced = &model.Unpriced{
+		Reason:  reason,
+		Message: message,
+	}
+}
+
+// apiFailures returns 1 when a resource could not be priced because the
+// pricing API failed
+func apiFailures(resource *model.Resource) int {
+	if resource.Unpriced != nil && resource.Unpriced.Reason == model.ReasonAPIError {
+		return 1
+	}
+	return 0
+}
+
+// reportFailures adds one error for the resources the pricing API failed for.
+// Each is listed with its reason among the resources not priced.
+func reportFailures(report *model.Report, failed int) {
+	if failed > 0 {
+		report.AddError(fmt.Sprintf("%d resources could not be priced because the pricing API failed (see resources not priced)", failed))
+	}
+}
+
+// reportWarnings reports the parts of a resource left out of its price
+func reportWarnings(report *model.Report, resource *model.Resource) {
+	for _, warning := range resource.Warnings {
+		report.AddWarning(fmt.Sprintf("%s: %s", resource.ID, warning))
+	}
 }
@@ -0,0 +1,78 @@
+package controller
+
+import (
+	"sort"
+
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+)
+
+// TypeCoverage describes how one resource type found in the IaC files is priced
+type TypeCoverage struct {
+	ResourceType string           `json:"resource_type"`
+	Provider     string           `json:"provider"`
+	Status       pricing.Coverage `json:"status"`
+	Count        int              `json:"count"` // Number of resource blocks of this type
+}
+
+// CoverageReport summarizes how much of the infrastructure can be priced
+type CoverageReport struct {
+	Types          []TypeCoverage           `json:"types"`
+	Counts         map[pricing.Coverage]int `json:"counts"` // Resource blocks per status
+	TotalResources int                      `json:"total_resources"`
+	Percent        float64                  `json:"coverage_percent"` // Share of resources not unsupported
+}
+
+// Coverage parses IaC files and reports which resource types can be priced
+func (e *Estimator) Coverage(path string) (*CoverageReport, error) {
+	resources, err := e.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CoverageReport{
+		Counts: make(map[pricing.Coverage]int),
+	}
+
+	byType := make(map[string]*TypeCoverage)
+	for _, resource := range resources {
+		coverage, ok := byType[resource.ResourceType]
+		if !ok {
+			coverage = &TypeCoverage{
+				ResourceType: resource.ResourceType,
+				Provider:     resource.Provider,
+				Status:       e.coverageFor(resource.Provider, resource.ResourceType),
+			}
+			byType[resource.ResourceType] = coverage
+		}
+
+		coverage.Count++
+		report.Counts[coverage.Status]++
+		report.TotalResources++
+	}
+
+	for _, coverage := range byType {
+		report.Types = append(report.Types, *coverage)
+	}
+	sort.Slice(report.Types, func(i, j int) bool {
+		return report.Types[i].ResourceType < report.Types[j].ResourceType
+	})
+
+	if report.TotalResources > 0 {
+		covered := report.TotalResources - report.Counts[pricing.CoverageUnsupported]
+		report.Percent = float64(covered) / float64(report.TotalResources) * 100
+	}
+
+	return report, nil
+}
+
+// coverageFor asks the provider's pricing client how it handles a resource type
+func (e *Estimator) coverageFor(provider, resourceType string) pricing.Coverage {
+	reporter, ok := e.PricingClients[provider].(pricing.CoverageReporter)
+	if !ok {
+		if pricing.IsFree(provider, resourceType) {
+			return pricing.CoverageFree
+		}
+		return pricing.CoverageUnsupported
+	}
+	return reporter.Coverage(resourceType)
+}
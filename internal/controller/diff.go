@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// DiffReports compares a current report against a previous one and returns the
+// current report annotated with added, removed and changed resources
+func DiffReports(previous, current *model.Report) *model.Report {
+	// Index previous resources by ID, recalculating totals for older reports
+	previousByID := make(map[string]*model.Resource)
+	for i := range previous.Resources {
+		resource := previous.Resources[i]
+		resource.CalculateTotals()
+		previousByID[resource.ID] = &resource
+	}
+
+	currentIDs := make(map[string]bool)
+	for i := range current.Resources {
+		newResource := current.Resources[i]
+		currentIDs[newResource.ID] = true
+
+		oldResource, ok := previousByID[newResource.ID]
+		if !ok {
+			current.AddedResources = append(current.AddedResources, newResource)
+			continue
+		}
+
+		changes := diffResource(oldResource, &newResource)
+		priceDiff := newResource.TotalMonthly - oldResource.TotalMonthly
+		if len(changes) == 0 && priceDiff == 0 {
+			continue
+		}
+
+		current.ChangedResources = append(current.ChangedResources, model.ResourceDiff{
+			ResourceID:  newResource.ID,
+			OldResource: oldResource,
+			NewResource: &newResource,
+			PriceDiff:   priceDiff,
+			Changes:     changes,
+		})
+	}
+
+	for _, resource := range previous.Resources {
+		if !currentIDs[resource.ID] {
+			resource.CalculateTotals()
+			current.RemovedResources = append(current.RemovedResources, resource)
+		}
+	}
+
+	current.IsDiff = true
+	current.PreviousReportID = previous.ReportID
+	current.PriceDiff = current.TotalMonthly - previous.TotalMonthly
+	if previous.TotalMonthly != 0 {
+		current.PriceDiffPercent = current.PriceDiff / previous.TotalMonthly * 100
+	}
+
+	return current
+}
+
+// diffResource lists the cost-relevant property changes between two versions of a resource
+func diffResource(oldResource, newResource *model.Resource) []model.Change {
+	var changes []model.Change
+
+	if oldResource.Size != newResource.Size {
+		changes = append(changes, model.Change{
+			Property:     "size",
+			OldValue:     oldResource.Size,
+			NewValue:     newResource.Size,
+			ImpactOnCost: (newResource.MonthlyPrice - oldResource.MonthlyPrice) * float64(newResource.Quantity),
+		})
+	}
+
+	if oldResource.Quantity != newResource.Quantity {
+		changes = append(changes, model.Change{
+			Property:     "quantity",
+			OldValue:     fmt.Sprintf("%d", oldResource.Quantity),
+			NewValue:     fmt.Sprintf("%d", newResource.Quantity),
+			ImpactOnCost: float64(newResource.Quantity-oldResource.Quantity) * oldResource.MonthlyPrice,
+		})
+	}
+
+	if oldResource.Region != newResource.Region {
+		changes = append(changes, model.Change{
+			Property: "region",
+			OldValue: oldResource.Region,
+			NewValue: newResource.Region,
+		})
+	}
+
+	return changes
+}
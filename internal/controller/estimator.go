@@ -1,12 +1,18 @@
 package controller
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/littleworks-inc/cloudcost/internal/calculator"
+	"github.com/littleworks-inc/cloudcost/internal/output"
 	"github.com/littleworks-inc/cloudcost/internal/parser"
+	"github.com/littleworks-inc/cloudcost/internal/policy"
 	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/internal/usage"
 	"github.com/littleworks-inc/cloudcost/internal/utils"
 	"github.com/littleworks-inc/cloudcost/pkg/model"
 )
@@ -16,6 +22,8 @@ type Estimator struct {
 	Parsers        []parser.Parser
 	PricingClients map[string]pricing.Client
 	Calculator     *calculator.Calculator
+	Usage          *usage.File   // Optional usage estimates for usage-based resources
+	Policy         policy.Config // Budget and policy limits checked against each report
 }
 
 // NewEstimator creates a new estimator
@@ -40,17 +48,84 @@ func (e *Estimator) RegisterPricingClient(provider string, client pricing.Client
 
 // Estimate performs cost estimation on IaC files
 func (e *Estimator) Estimate(path string) (*model.Report, error) {
+	iacType, resources, err := e.parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach usage estimates
+	if e.Usage != nil {
+		e.Usage.Apply(resources)
+	}
+
+	// Calculate costs
+	report, err := e.Calculator.CalculateCosts(resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate costs: %v", err)
+	}
+
+	// Set report metadata
+	report.IaCFormat = string(iacType)
+	report.Timestamp = time.Now()
+	report.ReportID = newReportID()
+	report.PolicyResults = policy.Evaluate(report, e.Policy)
+
+	return report, nil
+}
+
+// EstimateStream performs cost estimation like Estimate, but passes each
+// resource to emit as soon as it is priced instead of collecting them in the
+// report. The returned report holds only totals, breakdowns and checks. All
+// resources are still parsed before the first is priced.
+func (e *Estimator) EstimateStream(path string, emit func(*model.Resource) error) (*model.Report, error) {
+	iacType, resources, err := e.parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Attach usage estimates
+	if e.Usage != nil {
+		e.Usage.Apply(resources)
+	}
+
+	// Check each resource as it is priced
+	checker := policy.NewChecker(e.Policy)
+	report, err := e.Calculator.CalculateCostsStream(resources, func(resource *model.Resource) error {
+		checker.Observe(resource)
+		return emit(resource)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to calculate costs: %v", err)
+	}
+
+	// Set report metadata
+	report.IaCFormat = string(iacType)
+	report.Timestamp = time.Now()
+	report.ReportID = newReportID()
+	report.PolicyResults = checker.Results(report)
+
+	return report, nil
+}
+
+// Parse extracts resources from IaC files without pricing them
+func (e *Estimator) Parse(path string) ([]model.Resource, error) {
+	_, resources, err := e.parse(path)
+	return resources, err
+}
+
+// parse detects the IaC type and parses the files with a matching parser
+func (e *Estimator) parse(path string) (utils.IaCType, []model.Resource, error) {
 	// Detect IaC type
 	iacType, err := utils.DetectIaCType(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to detect IaC type: %v", err)
+		return utils.TypeUnknown, nil, fmt.Errorf("failed to detect IaC type: %v", err)
 	}
 
 	if iacType == utils.TypeUnknown {
-		return nil, fmt.Errorf("could not determine IaC type for path: %s", path)
+		return iacType, nil, fmt.Errorf("could not determine IaC type for path: %s", path)
 	}
 
-	fmt.Printf("Detected IaC type: %s\n", iacType)
+	fmt.Fprintf(os.Stderr, "Detected IaC type: %s\n", iacType)
 
 	// Find appropriate parser
 	var selectedParser parser.Parser
@@ -62,41 +137,48 @@ func (e *Estimator) Estimate(path string) (*model.Report, error) {
 	}
 
 	if selectedParser == nil {
-		return nil, fmt.Errorf("no parser available for IaC type: %s", iacType)
+		return iacType, nil, fmt.Errorf("no parser available for IaC type: %s", iacType)
 	}
 
-	fmt.Printf("Using parser: %s\n", selectedParser.GetName())
+	fmt.Fprintf(os.Stderr, "Using parser: %s\n", selectedParser.GetName())
 
 	// Parse IaC files
 	resources, err := selectedParser.Parse(path)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse IaC files: %v", err)
+		return iacType, nil, fmt.Errorf("failed to parse IaC files: %v", err)
 	}
 
-	fmt.Printf("Parsed %d resources\n", len(resources))
-
-	// Calculate costs
-	report, err := e.Calculator.CalculateCosts(resources)
-	if err != nil {
-		return nil, fmt.Errorf("failed to calculate costs: %v", err)
-	}
+	fmt.Fprintf(os.Stderr, "Parsed %d resources\n", len(resources))
 
-	// Set report metadata
-	report.IaCFormat = string(iacType)
-	report.Timestamp = time.Now()
-
-	return report, nil
+	return iacType, resources, nil
 }
 
 // Compare compares current IaC costs with a previous report
 func (e *Estimator) Compare(path string, previousReportPath string) (*model.Report, error) {
+	// Load the baseline before doing any pricing work
+	previousReport, err := output.LoadReport(previousReportPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous report: %v", err)
+	}
+
 	// Estimate current costs
 	currentReport, err := e.Estimate(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to estimate current costs: %v", err)
 	}
 
-	// TODO: Load previous report and compare
-	// For now, just return current report
-	return currentReport, nil
+	// Re-run the checks now that cost changes are known
+	report := DiffReports(previousReport, currentReport)
+	report.PolicyResults = policy.Evaluate(report, e.Policy)
+
+	return report, nil
+}
+
+// newReportID generates a random report identifier
+func newReportID() string {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(id)
 }
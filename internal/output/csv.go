@@ -0,0 +1,140 @@
+package output
+
+import (
+	"encoding/csv"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// csvColumns are the fixed columns written before the tag columns
+var csvColumns = []string{
+	"Address",
+	"Resource Type",
+	"Provider",
+	"Region",
+	"Size",
+	"Quantity",
+	"Price Component",
+	"Unit",
+	"Units",
+	"Unit Price",
+	"Unit Hourly Cost",
+	"Unit Monthly Cost",
+	"Total Hourly Cost",
+	"Total Monthly Cost",
+}
+
+// CSVFormatter formats reports as CSV with one row per resource, or one row
+// per price component for resources that have them
+type CSVFormatter struct{}
+
+// NewCSVFormatter creates a new CSV formatter
+func NewCSVFormatter() *CSVFormatter {
+	return &CSVFormatter{}
+}
+
+// Format formats the report as CSV
+func (f *CSVFormatter) Format(report *model.Report, writer io.Writer) error {
+	tagKeys := collectTagKeys(report.Resources)
+
+	w := csv.NewWriter(writer)
+
+	header := append([]string{}, csvColumns...)
+	for _, key := range tagKeys {
+		header = append(header, "tag:"+key)
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	for _, resource := range report.Resources {
+		tags := make([]string, len(tagKeys))
+		for i, key := range tagKeys {
+			tags[i] = resource.Tags[key]
+		}
+
+		var components []model.PriceComponent
+		if resource.PricingDetails != nil {
+			components = resource.PricingDetails.PriceComponents
+		}
+
+		// Resources without components get a single row with the resource price
+		if len(components) == 0 {
+			row := resourceColumns(resource)
+			row = append(row, "", "", "", "",
+				formatFloat(resource.HourlyPrice),
+				formatFloat(resource.MonthlyPrice),
+				formatFloat(resource.TotalHourly),
+				formatFloat(resource.TotalMonthly),
+			)
+			if err := w.Write(append(row, tags...)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Component totals are monthly costs for a single instance
+		quantity := float64(resource.Quantity)
+		for _, component := range components {
+			row := resourceColumns(resource)
+			row = append(row,
+				component.Name,
+				component.Unit,
+				formatFloat(component.Units),
+				formatFloat(component.UnitPrice),
+				formatFloat(component.Total/730),
+				formatFloat(component.Total),
+				formatFloat(component.Total/730*quantity),
+				formatFloat(component.Total*quantity),
+			)
+			if err := w.Write(append(row, tags...)); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// GetName returns the name of the formatter
+func (f *CSVFormatter) GetName() string {
+	return "csv"
+}
+
+// resourceColumns returns the identifying columns of a resource row
+func resourceColumns(resource model.Resource) []string {
+	return []string{
+		resource.ID,
+		resource.ResourceType,
+		resource.Provider,
+		resource.Region,
+		resource.Size,
+		strconv.Itoa(resource.Quantity),
+	}
+}
+
+// collectTagKeys returns the sorted union of tag keys across resources
+func collectTagKeys(resources []model.Resource) []string {
+	seen := make(map[string]bool)
+	for _, resource := range resources {
+		for key := range resource.Tags {
+			seen[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatFloat formats a number without losing precision
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
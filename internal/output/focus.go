@@ -0,0 +1,222 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// focusColumns are the FinOps FOCUS columns written by the FOCUS formatter.
+// Columns prefixed with x_ are custom columns allowed by the specification.
+var focusColumns = []string{
+	"BilledCost",
+	"BillingCurrency",
+	"BillingPeriodStart",
+	"BillingPeriodEnd",
+	"ChargeCategory",
+	"ChargeDescription",
+	"ChargePeriodStart",
+	"ChargePeriodEnd",
+	"ContractedCost",
+	"ContractedUnitPrice",
+	"EffectiveCost",
+	"ListCost",
+	"ListUnitPrice",
+	"PricingQuantity",
+	"PricingUnit",
+	"ProviderName",
+	"PublisherName",
+	"InvoiceIssuerName",
+	"RegionId",
+	"ResourceId",
+	"ResourceName",
+	"ResourceType",
+	"ServiceCategory",
+	"ServiceName",
+	"Tags",
+	"x_CostSource",
+	"x_ReportId",
+}
+
+// focusProviderNames maps Terraform providers to FOCUS provider names
+var focusProviderNames = map[string]string{
+	"aws":     "AWS",
+	"azurerm": "Microsoft",
+	"google":  "Google Cloud",
+}
+
+// focusService is the FOCUS service classification of resource types with a
+// given prefix
+type focusService struct {
+	Prefix   string
+	Category string
+	Name     string
+}
+
+// focusServices classifies resource types by prefix. The first matching entry
+// wins, so list longer prefixes before shorter ones.
+var focusServices = []focusService{
+	{"aws_instance", "Compute", "Amazon Elastic Compute Cloud"},
+	{"aws_ebs_", "Storage", "Amazon Elastic Block Store"},
+	{"aws_launch_", "Compute", "Amazon Elastic Compute Cloud"},
+	{"aws_autoscaling_", "Compute", "Amazon EC2 Auto Scaling"},
+	{"aws_lambda_", "Compute", "AWS Lambda"},
+	{"aws_ecs_", "Compute", "Amazon Elastic Container Service"},
+	{"aws_eks_", "Compute", "Amazon Elastic Kubernetes Service"},
+	{"aws_db_", "Databases", "Amazon Relational Database Service"},
+	{"aws_rds_", "Databases", "Amazon Relational Database Service"},
+	{"aws_dynamodb_", "Databases", "Amazon DynamoDB"},
+	{"aws_elasticache_", "Databases", "Amazon ElastiCache"},
+	{"aws_s3_", "Storage", "Amazon Simple Storage Service"},
+	{"aws_efs_", "Storage", "Amazon Elastic File System"},
+	{"aws_cloudfront_", "Networking", "Amazon CloudFront"},
+	{"aws_route53_", "Networking", "Amazon Route 53"},
+	{"aws_lb", "Networking", "Elastic Load Balancing"},
+	{"aws_elb", "Networking", "Elastic Load Balancing"},
+	{"aws_nat_gateway", "Networking", "Amazon Virtual Private Cloud"},
+	{"aws_eip", "Networking", "Amazon Virtual Private Cloud"},
+	{"aws_vpc", "Networking", "Amazon Virtual Private Cloud"},
+	{"aws_iam_", "Identity", "AWS Identity and Access Management"},
+	{"aws_kms_", "Security", "AWS Key Management Service"},
+	{"aws_sqs_", "Integration", "Amazon Simple Queue Service"},
+	{"aws_sns_", "Integration", "Amazon Simple Notification Service"},
+	{"aws_cloudwatch_", "Management and Governance", "Amazon CloudWatch"},
+	{"azurerm_linux_virtual_machine", "Compute", "Virtual Machines"},
+	{"azurerm_windows_virtual_machine", "Compute", "Virtual Machines"},
+	{"azurerm_virtual_machine", "Compute", "Virtual Machines"},
+	{"azurerm_managed_disk", "Storage", "Storage"},
+	{"azurerm_storage_", "Storage", "Storage"},
+	{"azurerm_mssql_", "Databases", "SQL Database"},
+	{"azurerm_sql_", "Databases", "SQL Database"},
+	{"google_compute_instance", "Compute", "Compute Engine"},
+	{"google_compute_disk", "Storage", "Compute Engine"},
+	{"google_storage_", "Storage", "Cloud Storage"},
+	{"google_sql_", "Databases", "Cloud SQL"},
+}
+
+// FOCUSFormatter formats reports as CSV following the FinOps FOCUS
+// specification, with one row per priced resource or price component
+type FOCUSFormatter struct{}
+
+// NewFOCUSFormatter creates a new FOCUS formatter
+func NewFOCUSFormatter() *FOCUSFormatter {
+	return &FOCUSFormatter{}
+}
+
+// Format formats the report as FOCUS CSV
+func (f *FOCUSFormatter) Format(report *model.Report, writer io.Writer) error {
+	// Estimates cover one month, charged in the month the report was generated
+	timestamp := report.Timestamp.UTC()
+	periodStart := time.Date(timestamp.Year(), timestamp.Month(), 1, 0, 0, 0, 0, time.UTC)
+	periodEnd := periodStart.AddDate(0, 1, 0)
+
+	w := csv.NewWriter(writer)
+	if err := w.Write(focusColumns); err != nil {
+		return err
+	}
+
+	for _, resource := range report.Resources {
+		if resource.Unpriced != nil {
+			continue
+		}
+
+		tags := ""
+		if len(resource.Tags) > 0 {
+			data, err := json.Marshal(resource.Tags)
+			if err != nil {
+				return err
+			}
+			tags = string(data)
+		}
+
+		category, service := focusServiceFor(resource.ResourceType)
+		currency := "USD"
+		if resource.PricingDetails != nil && resource.PricingDetails.Currency != "" {
+			currency = resource.PricingDetails.Currency
+		}
+
+		row := func(description, unit string, quantity, unitPrice, cost float64) []string {
+			return []string{
+				formatFloat(cost),
+				currency,
+				periodStart.Format(time.RFC3339),
+				periodEnd.Format(time.RFC3339),
+				"Usage",
+				description,
+				periodStart.Format(time.RFC3339),
+				periodEnd.Format(time.RFC3339),
+				formatFloat(cost),
+				formatFloat(unitPrice),
+				formatFloat(cost),
+				formatFloat(cost),
+				formatFloat(unitPrice),
+				formatFloat(quantity),
+				unit,
+				focusProviderName(resource.Provider),
+				focusProviderName(resource.Provider),
+				focusProviderName(resource.Provider),
+				resource.Region,
+				resource.ID,
+				resource.Name,
+				resource.ResourceType,
+				category,
+				service,
+				tags,
+				"estimate",
+				report.ReportID,
+			}
+		}
+
+		var components []model.PriceComponent
+		if resource.PricingDetails != nil {
+			components = resource.PricingDetails.PriceComponents
+		}
+
+		// Resources without components are charged by the hour
+		quantity := float64(resource.Quantity)
+		if len(components) == 0 {
+			if err := w.Write(row(resource.ResourceType, "Hrs", 730*quantity, resource.HourlyPrice, resource.TotalMonthly)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Component totals are monthly costs for a single instance
+		for _, component := range components {
+			if err := w.Write(row(component.Name, component.Unit, component.Units*quantity, component.UnitPrice, component.Total*quantity)); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
+
+// GetName returns the name of the formatter
+func (f *FOCUSFormatter) GetName() string {
+	return "focus"
+}
+
+// focusProviderName returns the FOCUS provider name of a Terraform provider
+func focusProviderName(provider string) string {
+	if name, ok := focusProviderNames[provider]; ok {
+		return name
+	}
+	return provider
+}
+
+// focusServiceFor returns the FOCUS service category and service name of a
+// resource type
+func focusServiceFor(resourceType string) (string, string) {
+	for _, service := range focusServices {
+		if strings.HasPrefix(resourceType, service.Prefix) {
+			return service.Category, service.Name
+		}
+	}
+	return "Other", resourceType
+}
@@ -0,0 +1,186 @@
+package output
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// untaggedGroup is the group name for resources without the requested tag
+const untaggedGroup = "(untagged)"
+
+// unknownModuleGroup is the group name for resources without a source file
+const unknownModuleGroup = "(unknown)"
+
+// ResourceGroup is a named set of resources with their combined monthly cost
+type ResourceGroup struct {
+	Name         string
+	Resources    []model.Resource
+	TotalMonthly float64
+}
+
+// chartBar is one entry of a cost breakdown
+type chartBar struct {
+	Label   string
+	Value   float64
+	Percent float64 // Width relative to the largest bar
+}
+
+// TemplateFuncs returns the helper functions available to text and HTML report
+// templates:
+//
+//	currency AMOUNT            "$1,234.56"
+//	percent PART TOTAL         PART as a share of TOTAL, e.g. "12.5%"
+//	sortByCost RESOURCES       resources by descending monthly cost
+//	breakdown MAP              a ByProvider/ByRegion/... map as entries with
+//	                           .Label, .Value and .Percent, by descending cost
+//	groupByTag KEY RESOURCES   groups (.Name, .Resources, .TotalMonthly) per
+//	                           value of tag KEY, by descending cost
+//	groupByModule RESOURCES    groups per module directory, by name
+func TemplateFuncs() map[string]interface{} {
+	return map[string]interface{}{
+		"currency":      formatCurrency,
+		"percent":       formatPercent,
+		"sortByCost":    sortByCost,
+		"breakdown":     chartBars,
+		"groupByTag":    groupByTag,
+		"groupByModule": groupByModule,
+	}
+}
+
+// formatCurrency formats a dollar amount with thousands separators
+func formatCurrency(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	s := fmt.Sprintf("%.2f", amount)
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+
+	var b strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(digit)
+	}
+
+	return sign + "$" + b.String() + cents
+}
+
+// formatPercent formats part as a percentage of total
+func formatPercent(part, total float64) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", part/total*100)
+}
+
+// sortByCost returns a copy of the resources sorted by descending monthly cost
+func sortByCost(resources []model.Resource) []model.Resource {
+	sorted := make([]model.Resource, len(resources))
+	copy(sorted, resources)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].TotalMonthly != sorted[j].TotalMonthly {
+			return sorted[i].TotalMonthly > sorted[j].TotalMonthly
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	return sorted
+}
+
+// groupByTag groups resources by the value of a tag, by descending cost
+func groupByTag(key string, resources []model.Resource) []ResourceGroup {
+	groups := groupResources(resources, func(resource model.Resource) string {
+		if value, ok := resource.Tags[key]; ok {
+			return value
+		}
+		return untaggedGroup
+	})
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].TotalMonthly != groups[j].TotalMonthly {
+			return groups[i].TotalMonthly > groups[j].TotalMonthly
+		}
+		return groups[i].Name < groups[j].Name
+	})
+
+	return groups
+}
+
+// groupByModule groups resources by the directory of their source file, as
+// annotate does, with resources without a source last
+func groupByModule(resources []model.Resource) []ResourceGroup {
+	modules := groupResources(resources, func(resource model.Resource) string {
+		if module := resource.Module(); module != "" {
+			return module
+		}
+		return unknownModuleGroup
+	})
+
+	sort.SliceStable(modules, func(i, j int) bool {
+		if modules[i].Name == unknownModuleGroup || modules[j].Name == unknownModuleGroup {
+			return modules[j].Name == unknownModuleGroup && modules[i].Name != unknownModuleGroup
+		}
+		return modules[i].Name < modules[j].Name
+	})
+
+	return modules
+}
+
+// groupResources groups resources by the name returned for each, in order of
+// first appearance
+func groupResources(resources []model.Resource, name func(model.Resource) string) []ResourceGroup {
+	index := make(map[string]int)
+	var groups []ResourceGroup
+
+	for _, resource := range resources {
+		key := name(resource)
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, ResourceGroup{Name: key})
+		}
+		groups[i].Resources = append(groups[i].Resources, resource)
+		groups[i].TotalMonthly += resource.TotalMonthly
+	}
+
+	return groups
+}
+
+// chartBars converts a breakdown into bars sorted by descending cost
+func chartBars(breakdown map[string]float64) []chartBar {
+	bars := make([]chartBar, 0, len(breakdown))
+	var largest float64
+	for label, value := range breakdown {
+		if label == "" {
+			label = "(none)"
+		}
+		bars = append(bars, chartBar{Label: label, Value: value})
+		if value > largest {
+			largest = value
+		}
+	}
+
+	sort.Slice(bars, func(i, j int) bool {
+		if bars[i].Value != bars[j].Value {
+			return bars[i].Value > bars[j].Value
+		}
+		return bars[i].Label < bars[j].Label
+	})
+
+	if largest > 0 {
+		for i := range bars {
+			bars[i].Percent = bars[i].Value / largest * 100
+		}
+	}
+
+	return bars
+}
@@ -0,0 +1,78 @@
+package output
+
+import (
+	"html/template"
+	"io"
+	"path/filepath"
+	"sort"
+
+	"github.com/littleworks-inc/cloudcost/configs"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// defaultHTMLTemplate is the embedded template used when no path is given
+const defaultHTMLTemplate = "templates/html_report.tmpl"
+
+// HTMLFormatter formats reports as a self-contained HTML page
+type HTMLFormatter struct {
+	TemplatePath string // Template file to use instead of the embedded default
+}
+
+// htmlReport is the data passed to the HTML template
+type htmlReport struct {
+	*model.Report
+	Modules       []ResourceGroup
+	Providers     []chartBar
+	Regions       []chartBar
+	ResourceTypes []chartBar
+	Tags          []tagChart
+}
+
+// tagChart is the breakdown chart for one tag key
+type tagChart struct {
+	Key  string
+	Bars []chartBar
+}
+
+// NewHTMLFormatter creates a new HTML formatter
+func NewHTMLFormatter(templatePath string) *HTMLFormatter {
+	return &HTMLFormatter{
+		TemplatePath: templatePath,
+	}
+}
+
+// Format formats the report as HTML
+func (f *HTMLFormatter) Format(report *model.Report, writer io.Writer) error {
+	var tmpl *template.Template
+	var err error
+	if f.TemplatePath == "" {
+		tmpl, err = template.New(filepath.Base(defaultHTMLTemplate)).Funcs(TemplateFuncs()).ParseFS(configs.Templates, defaultHTMLTemplate)
+	} else {
+		tmpl, err = template.New(filepath.Base(f.TemplatePath)).Funcs(TemplateFuncs()).ParseFiles(f.TemplatePath)
+	}
+	if err != nil {
+		return err
+	}
+
+	data := &htmlReport{
+		Report:        report,
+		Modules:       groupByModule(report.Resources),
+		Providers:     chartBars(report.ByProvider),
+		Regions:       chartBars(report.ByRegion),
+		ResourceTypes: chartBars(report.ByResourceType),
+	}
+
+	for key, values := range report.ByTag {
+		data.Tags = append(data.Tags, tagChart{Key: key, Bars: chartBars(values)})
+	}
+	sort.Slice(data.Tags, func(i, j int) bool {
+		return data.Tags[i].Key < data.Tags[j].Key
+	})
+
+	return tmpl.Execute(writer, data)
+}
+
+// GetName returns the name of the formatter
+func (f *HTMLFormatter) GetName() string {
+	return "html"
+}
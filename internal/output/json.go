@@ -0,0 +1,55 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// JSONFormatter formats reports as JSON
+type JSONFormatter struct{}
+
+// NewJSONFormatter creates a new JSON formatter
+func NewJSONFormatter() *JSONFormatter {
+	return &JSONFormatter{}
+}
+
+// Format formats the report as indented JSON
+func (f *JSONFormatter) Format(report *model.Report, writer io.Writer) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(report)
+}
+
+// GetName returns the name of the formatter
+func (f *JSONFormatter) GetName() string {
+	return "json"
+}
+
+// ReadJSON reads a report written by the JSON formatter
+func ReadJSON(reader io.Reader) (*model.Report, error) {
+	// Decode over an empty report so absent collections come back initialized
+	report := model.NewReport()
+	if err := json.NewDecoder(reader).Decode(report); err != nil {
+		return nil, fmt.Errorf("failed to decode report: %v", err)
+	}
+	return report, nil
+}
+
+// LoadReport loads a JSON report from a file
+func LoadReport(filename string) (*model.Report, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open report %s: %v", filename, err)
+	}
+	defer file.Close()
+
+	report, err := ReadJSON(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load report %s: %v", filename, err)
+	}
+	return report, nil
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// JUnit XML report structure as understood by common CI systems
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Timestamp string          `xml:"timestamp,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	File      string        `xml:"file,attr,omitempty"`
+	Line      int           `xml:"line,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// JUnitFormatter formats budget and policy checks as JUnit XML, with one
+// testcase per check that fails when the limit is breached
+type JUnitFormatter struct{}
+
+// NewJUnitFormatter creates a new JUnit formatter
+func NewJUnitFormatter() *JUnitFormatter {
+	return &JUnitFormatter{}
+}
+
+// Format formats the report as JUnit XML
+func (f *JUnitFormatter) Format(report *model.Report, writer io.Writer) error {
+	suites := junitTestSuites{Name: "cloudcost"}
+
+	// One suite per kind of check, in a fixed order
+	for _, kind := range []model.PolicyKind{model.PolicyKindBudget, model.PolicyKindPolicy} {
+		suite := junitTestSuite{Name: "cloudcost." + string(kind)}
+		if !report.Timestamp.IsZero() {
+			suite.Timestamp = report.Timestamp.Format("2006-01-02T15:04:05")
+		}
+
+		for _, result := range report.PolicyResults {
+			if result.Kind != kind {
+				continue
+			}
+
+			testCase := junitTestCase{
+				Name:      result.Check,
+				ClassName: suite.Name,
+				SystemOut: result.Message,
+			}
+			if result.ResourceID != "" && result.Kind == model.PolicyKindPolicy {
+				testCase.Name += " " + result.ResourceID
+			}
+			if result.Source != nil {
+				testCase.File = relativePath(result.Source.Filename)
+				testCase.Line = result.Source.StartLine
+			}
+
+			if !result.Passed {
+				testCase.Failure = &junitFailure{
+					Message: result.Message,
+					Type:    result.Check,
+					Text:    junitFailureText(report, result),
+				}
+				suite.Failures++
+			}
+
+			suite.Cases = append(suite.Cases, testCase)
+			suite.Tests++
+		}
+
+		if suite.Tests > 0 {
+			suites.Suites = append(suites.Suites, suite)
+			suites.Tests += suite.Tests
+			suites.Failures += suite.Failures
+		}
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(writer, "\n")
+	return err
+}
+
+// GetName returns the name of the formatter
+func (f *JUnitFormatter) GetName() string {
+	return "junit"
+}
+
+// junitFailureText describes a failed check with the cost numbers behind it
+func junitFailureText(report *model.Report, result model.PolicyResult) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", result.Description)
+	fmt.Fprintf(&b, "Actual:          %s\n", formatFloat(result.Actual))
+	fmt.Fprintf(&b, "Limit:           %s\n", formatFloat(result.Limit))
+	fmt.Fprintf(&b, "Total monthly:   $%.2f\n", report.TotalMonthly)
+	if report.IsDiff {
+		fmt.Fprintf(&b, "Monthly change:  %+.2f (%+.1f%%)\n", report.PriceDiff, report.PriceDiffPercent)
+	}
+	if result.ResourceID != "" {
+		fmt.Fprintf(&b, "Resource:        %s\n", result.ResourceID)
+	}
+	if result.Source != nil {
+		fmt.Fprintf(&b, "Location:        %s:%d\n", relativePath(result.Source.Filename), result.Source.StartLine)
+	}
+	return b.String()
+}
@@ -0,0 +1,232 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// Defaults keeping markdown output under pull-request comment size limits
+const (
+	defaultMarkdownMaxRows  = 25
+	defaultMarkdownMaxBytes = 60000 // GitHub rejects comments over 65536 characters
+)
+
+// MarkdownFormatter formats reports as GitHub-flavoured markdown for pull-request comments
+type MarkdownFormatter struct {
+	MaxRows  int // Maximum rows per table before a "+N more" row
+	MaxBytes int // Approximate upper bound on the output size
+}
+
+// markdownRow is one resource line of the cost table
+type markdownRow struct {
+	Resource   string
+	OldMonthly float64
+	NewMonthly float64
+	HasOld     bool
+	HasNew     bool
+	Details    string
+}
+
+// NewMarkdownFormatter creates a new markdown formatter
+func NewMarkdownFormatter() *MarkdownFormatter {
+	return &MarkdownFormatter{
+		MaxRows:  defaultMarkdownMaxRows,
+		MaxBytes: defaultMarkdownMaxBytes,
+	}
+}
+
+// Format formats the report as markdown
+func (f *MarkdownFormatter) Format(report *model.Report, writer io.Writer) error {
+	var b strings.Builder
+
+	b.WriteString("## Cloud cost estimate\n\n")
+
+	rows := markdownRows(report)
+	if report.IsDiff {
+		previousMonthly := report.TotalMonthly - report.PriceDiff
+		switch {
+		case report.PriceDiff > 0:
+			fmt.Fprintf(&b, "**Monthly cost will increase by %s (%+.1f%%)**\n\n", formatMoney(report.PriceDiff), report.PriceDiffPercent)
+		case report.PriceDiff < 0:
+			fmt.Fprintf(&b, "**Monthly cost will decrease by %s (%+.1f%%)**\n\n", formatMoney(-report.PriceDiff), report.PriceDiffPercent)
+		default:
+			b.WriteString("**Monthly cost will not change**\n\n")
+		}
+		fmt.Fprintf(&b, "%s → %s per month\n\n", formatMoney(previousMonthly), formatMoney(report.TotalMonthly))
+	} else {
+		fmt.Fprintf(&b, "**Estimated monthly cost: %s** (%s per year)\n\n", formatMoney(report.TotalMonthly), formatMoney(report.TotalYearly))
+	}
+
+	if len(rows) > 0 {
+		b.WriteString("| Resource | Old monthly | New monthly | Delta | % |\n")
+		b.WriteString("|---|---:|---:|---:|---:|\n")
+		f.writeRows(&b, rows, func(row markdownRow) string {
+			return fmt.Sprintf("| `%s` | %s | %s | %s | %s |\n",
+				escapeMarkdownCell(row.Resource),
+				optionalMoney(row.OldMonthly, row.HasOld),
+				optionalMoney(row.NewMonthly, row.HasNew),
+				formatSignedMoney(row.NewMonthly-row.OldMonthly),
+				formatPercentChange(row.OldMonthly, row.NewMonthly))
+		}, "| _+%d more_ | | | | |\n")
+		b.WriteString("\n")
+	}
+
+	// Collapsed details for changed properties and resources that were not priced
+	var details []markdownRow
+	for _, row := range rows {
+		if row.Details != "" {
+			details = append(details, row)
+		}
+	}
+	if len(details) > 0 {
+		b.WriteString("<details><summary>Resource changes</summary>\n\n")
+		f.writeRows(&b, details, func(row markdownRow) string {
+			return fmt.Sprintf("- `%s`: %s\n", row.Resource, escapeMarkdownCell(row.Details))
+		}, "- _+%d more_\n")
+		b.WriteString("\n</details>\n\n")
+	}
+
+	if unpriced := report.UnpricedCount(); unpriced > 0 {
+		fmt.Fprintf(&b, "<details><summary>%d resources not priced</summary>\n\n", unpriced)
+
+		groups := report.UnpricedByReason()
+		reasons := make([]string, 0, len(groups))
+		for reason := range groups {
+			reasons = append(reasons, string(reason))
+		}
+		sort.Strings(reasons)
+
+		var lines []markdownRow
+		for _, reason := range reasons {
+			for _, resource := range groups[model.UnpricedReason(reason)] {
+				lines = append(lines, markdownRow{
+					Resource: resource.ID,
+					Details:  model.UnpricedReason(reason).Description(),
+				})
+			}
+		}
+		f.writeRows(&b, lines, func(row markdownRow) string {
+			return fmt.Sprintf("- `%s`: %s\n", row.Resource, row.Details)
+		}, "- _+%d more_\n")
+		b.WriteString("\n</details>\n")
+	}
+
+	_, err := io.WriteString(writer, b.String())
+	return err
+}
+
+// GetName returns the name of the formatter
+func (f *MarkdownFormatter) GetName() string {
+	return "markdown"
+}
+
+// writeRows writes formatted rows, replacing the tail with a "+N more" line when
+// the row or size limit is reached
+func (f *MarkdownFormatter) writeRows(b *strings.Builder, rows []markdownRow, format func(markdownRow) string, moreFormat string) {
+	for i, row := range rows {
+		line := format(row)
+		if (f.MaxRows > 0 && i >= f.MaxRows) || (f.MaxBytes > 0 && b.Len()+len(line) > f.MaxBytes) {
+			fmt.Fprintf(b, moreFormat, len(rows)-i)
+			return
+		}
+		b.WriteString(line)
+	}
+}
+
+// markdownRows builds the cost table rows sorted by the size of the change
+func markdownRows(report *model.Report) []markdownRow {
+	var rows []markdownRow
+
+	if report.IsDiff {
+		for _, resource := range report.AddedResources {
+			rows = append(rows, markdownRow{
+				Resource:   resource.ID,
+				NewMonthly: resource.TotalMonthly,
+				HasNew:     true,
+				Details:    "added",
+			})
+		}
+		for _, resource := range report.RemovedResources {
+			rows = append(rows, markdownRow{
+				Resource:   resource.ID,
+				OldMonthly: resource.TotalMonthly,
+				HasOld:     true,
+				Details:    "removed",
+			})
+		}
+		for _, diff := range report.ChangedResources {
+			var changes []string
+			for _, change := range diff.Changes {
+				changes = append(changes, fmt.Sprintf("%s %s → %s", change.Property, change.OldValue, change.NewValue))
+			}
+			rows = append(rows, markdownRow{
+				Resource:   diff.ResourceID,
+				OldMonthly: diff.OldResource.TotalMonthly,
+				NewMonthly: diff.NewResource.TotalMonthly,
+				HasOld:     true,
+				HasNew:     true,
+				Details:    strings.Join(changes, ", "),
+			})
+		}
+	} else {
+		for _, resource := range report.Resources {
+			rows = append(rows, markdownRow{
+				Resource:   resource.ID,
+				NewMonthly: resource.TotalMonthly,
+				HasNew:     true,
+			})
+		}
+	}
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		di := math.Abs(rows[i].NewMonthly - rows[i].OldMonthly)
+		dj := math.Abs(rows[j].NewMonthly - rows[j].OldMonthly)
+		if di != dj {
+			return di > dj
+		}
+		return rows[i].Resource < rows[j].Resource
+	})
+
+	return rows
+}
+
+// formatMoney formats an amount in dollars
+func formatMoney(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
+
+// formatSignedMoney formats a change in dollars with an explicit sign
+func formatSignedMoney(amount float64) string {
+	if amount < 0 {
+		return fmt.Sprintf("-$%.2f", -amount)
+	}
+	return fmt.Sprintf("+$%.2f", amount)
+}
+
+// optionalMoney formats an amount, or a dash when there is no value
+func optionalMoney(amount float64, ok bool) string {
+	if !ok {
+		return "-"
+	}
+	return formatMoney(amount)
+}
+
+// formatPercentChange formats the relative change between two amounts
+func formatPercentChange(oldAmount, newAmount float64) string {
+	if oldAmount == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%+.1f%%", (newAmount-oldAmount)/oldAmount*100)
+}
+
+// escapeMarkdownCell escapes characters that would break a markdown table cell
+func escapeMarkdownCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	value = strings.ReplaceAll(value, "\n", " ")
+	return value
+}
@@ -0,0 +1,144 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// Values of the "type" field of each NDJSON line
+const (
+	ndjsonTypeResource = "resource"
+	ndjsonTypeSummary  = "summary"
+)
+
+// ndjsonResource is one resource line
+type ndjsonResource struct {
+	Type string `json:"type"`
+	*model.Resource
+}
+
+// ndjsonSummary is the final line, with everything in the report except the
+// resources themselves
+type ndjsonSummary struct {
+	Type          string    `json:"type"`
+	ReportID      string    `json:"report_id"`
+	ReportVersion string    `json:"report_version"`
+	Timestamp     time.Time `json:"timestamp"`
+	Currency      string    `json:"currency"`
+	IaCFormat     string    `json:"iac_format"`
+	ResourceCount int       `json:"resource_count"`
+	UnpricedCount int       `json:"unpriced_count"`
+
+	TotalHourly  float64 `json:"total_hourly"`
+	TotalMonthly float64 `json:"total_monthly"`
+	TotalYearly  float64 `json:"total_yearly"`
+
+	ByProvider     map[string]float64            `json:"by_provider,omitempty"`
+	ByResourceType map[string]float64            `json:"by_resource_type,omitempty"`
+	ByRegion       map[string]float64            `json:"by_region,omitempty"`
+	ByTag          map[string]map[string]float64 `json:"by_tag,omitempty"`
+
+	IsDiff           bool     `json:"is_diff,omitempty"`
+	PreviousReportID string   `json:"previous_report_id,omitempty"`
+	AddedResources   []string `json:"added_resources,omitempty"`
+	RemovedResources []string `json:"removed_resources,omitempty"`
+	ChangedResources []string `json:"changed_resources,omitempty"`
+	PriceDiff        float64  `json:"price_diff,omitempty"`
+	PriceDiffPercent float64  `json:"price_diff_percent,omitempty"`
+
+	PolicyResults []model.PolicyResult `json:"policy_results,omitempty"`
+	Errors        []string             `json:"errors,omitempty"`
+	Warnings      []string             `json:"warnings,omitempty"`
+}
+
+// NDJSONWriter writes resources as newline-delimited JSON as they arrive,
+// followed by a summary line
+type NDJSONWriter struct {
+	encoder   *json.Encoder
+	resources int
+	unpriced  int
+}
+
+// NewNDJSONWriter creates a new NDJSON writer
+func NewNDJSONWriter(writer io.Writer) *NDJSONWriter {
+	return &NDJSONWriter{
+		encoder: json.NewEncoder(writer),
+	}
+}
+
+// WriteResource writes one resource line
+func (w *NDJSONWriter) WriteResource(resource *model.Resource) error {
+	w.resources++
+	if resource.Unpriced != nil {
+		w.unpriced++
+	}
+	return w.encoder.Encode(ndjsonResource{Type: ndjsonTypeResource, Resource: resource})
+}
+
+// WriteSummary writes the summary line for the resources written so far
+func (w *NDJSONWriter) WriteSummary(report *model.Report) error {
+	summary := ndjsonSummary{
+		Type:             ndjsonTypeSummary,
+		ReportID:         report.ReportID,
+		ReportVersion:    report.ReportVersion,
+		Timestamp:        report.Timestamp,
+		Currency:         report.Currency,
+		IaCFormat:        report.IaCFormat,
+		ResourceCount:    w.resources,
+		UnpricedCount:    w.unpriced,
+		TotalHourly:      report.TotalHourly,
+		TotalMonthly:     report.TotalMonthly,
+		TotalYearly:      report.TotalYearly,
+		ByProvider:       report.ByProvider,
+		ByResourceType:   report.ByResourceType,
+		ByRegion:         report.ByRegion,
+		ByTag:            report.ByTag,
+		IsDiff:           report.IsDiff,
+		PreviousReportID: report.PreviousReportID,
+		PriceDiff:        report.PriceDiff,
+		PriceDiffPercent: report.PriceDiffPercent,
+		PolicyResults:    report.PolicyResults,
+		Errors:           report.Errors,
+		Warnings:         report.Warnings,
+	}
+
+	for _, resource := range report.AddedResources {
+		summary.AddedResources = append(summary.AddedResources, resource.ID)
+	}
+	for _, resource := range report.RemovedResources {
+		summary.RemovedResources = append(summary.RemovedResources, resource.ID)
+	}
+	for _, diff := range report.ChangedResources {
+		summary.ChangedResources = append(summary.ChangedResources, diff.ResourceID)
+	}
+
+	return w.encoder.Encode(summary)
+}
+
+// NDJSONFormatter formats a complete report as newline-delimited JSON: one
+// line per resource followed by a summary line
+type NDJSONFormatter struct{}
+
+// NewNDJSONFormatter creates a new NDJSON formatter
+func NewNDJSONFormatter() *NDJSONFormatter {
+	return &NDJSONFormatter{}
+}
+
+// Format formats the report as NDJSON
+func (f *NDJSONFormatter) Format(report *model.Report, writer io.Writer) error {
+	w := NewNDJSONWriter(writer)
+	for i := range report.Resources {
+		if err := w.WriteResource(&report.Resources[i]); err != nil {
+			return err
+		}
+	}
+	return w.WriteSummary(report)
+}
+
+// GetName returns the name of the formatter
+func (f *NDJSONFormatter) GetName() string {
+	return "ndjson"
+}
@@ -0,0 +1,181 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// SARIF 2.1.0 log structure, limited to the properties the formatter writes
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string       `json:"id"`
+	ShortDescription sarifMessage `json:"shortDescription"`
+	Properties       struct {
+		Kind model.PolicyKind `json:"kind"`
+	} `json:"properties"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation  `json:"physicalLocation"`
+	LogicalLocations []sarifLogicalLocation `json:"logicalLocations,omitempty"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+	Region           sarifRegion           `json:"region"`
+}
+
+type sarifArtifactLocation struct {
+	URI       string `json:"uri"`
+	URIBaseID string `json:"uriBaseId,omitempty"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+	EndLine     int `json:"endLine,omitempty"`
+	EndColumn   int `json:"endColumn,omitempty"`
+}
+
+type sarifLogicalLocation struct {
+	FullyQualifiedName string `json:"fullyQualifiedName"`
+	Kind               string `json:"kind"`
+}
+
+// SARIFFormatter formats failed budget and policy checks as SARIF findings for
+// code-scanning tools, located at the resource block they point at
+type SARIFFormatter struct{}
+
+// NewSARIFFormatter creates a new SARIF formatter
+func NewSARIFFormatter() *SARIFFormatter {
+	return &SARIFFormatter{}
+}
+
+// Format formats the report as a SARIF log
+func (f *SARIFFormatter) Format(report *model.Report, writer io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{Driver: sarifDriver{
+			Name:           "cloudcost",
+			InformationURI: "https://github.com/littleworks-inc/cloudcost",
+			Rules:          []sarifRule{},
+		}},
+		Results: []sarifResult{},
+	}
+
+	// Every check that ran becomes a rule, so passing runs clear old findings
+	ruleIndex := make(map[string]int)
+	for _, result := range report.PolicyResults {
+		if _, ok := ruleIndex[result.Check]; ok {
+			continue
+		}
+		rule := sarifRule{ID: result.Check, ShortDescription: sarifMessage{Text: result.Description}}
+		rule.Properties.Kind = result.Kind
+		ruleIndex[result.Check] = len(run.Tool.Driver.Rules)
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, rule)
+	}
+
+	for _, result := range report.PolicyFailures() {
+		finding := sarifResult{
+			RuleID:    result.Check,
+			RuleIndex: ruleIndex[result.Check],
+			Level:     "error",
+			Message:   sarifMessage{Text: result.Message},
+			Properties: map[string]interface{}{
+				"actual": result.Actual,
+				"limit":  result.Limit,
+			},
+		}
+
+		if result.Source != nil {
+			location := sarifLocation{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifactLocation{
+						URI:       relativePath(result.Source.Filename),
+						URIBaseID: "%SRCROOT%",
+					},
+					Region: sarifRegion{
+						StartLine:   result.Source.StartLine,
+						StartColumn: result.Source.StartColumn,
+						EndLine:     result.Source.EndLine,
+						EndColumn:   result.Source.EndColumn,
+					},
+				},
+			}
+			if result.ResourceID != "" {
+				location.LogicalLocations = []sarifLogicalLocation{{
+					FullyQualifiedName: result.ResourceID,
+					Kind:               "resource",
+				}}
+			}
+			finding.Locations = []sarifLocation{location}
+		}
+
+		run.Results = append(run.Results, finding)
+	}
+
+	log := sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{run},
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(log)
+}
+
+// GetName returns the name of the formatter
+func (f *SARIFFormatter) GetName() string {
+	return "sarif"
+}
+
+// relativePath converts a file path into a slash-separated path relative to
+// the working directory, which CI tools resolve against the repository root
+func relativePath(filename string) string {
+	if filepath.IsAbs(filename) {
+		if wd, err := os.Getwd(); err == nil {
+			if rel, err := filepath.Rel(wd, filename); err == nil {
+				filename = rel
+			}
+		}
+	}
+	return filepath.ToSlash(filepath.Clean(filename))
+}
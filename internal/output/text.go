@@ -2,21 +2,23 @@ package output
 
 import (
 	"io"
+	"path/filepath"
 	"text/template"
 
+	"github.com/littleworks-inc/cloudcost/configs"
 	"github.com/littleworks-inc/cloudcost/pkg/model"
 )
 
+// defaultTextTemplate is the embedded template used when no path is given
+const defaultTextTemplate = "templates/text_report.tmpl"
+
 // TextFormatter formats reports as plain text
 type TextFormatter struct {
-	TemplatePath string
+	TemplatePath string // Template file to use instead of the embedded default
 }
 
 // NewTextFormatter creates a new text formatter
 func NewTextFormatter(templatePath string) *TextFormatter {
-	if templatePath == "" {
-		templatePath = "configs/templates/text_report.tmpl"
-	}
 	return &TextFormatter{
 		TemplatePath: templatePath,
 	}
@@ -24,7 +26,13 @@ func NewTextFormatter(templatePath string) *TextFormatter {
 
 // Format formats the report as text
 func (f *TextFormatter) Format(report *model.Report, writer io.Writer) error {
-	tmpl, err := template.ParseFiles(f.TemplatePath)
+	var tmpl *template.Template
+	var err error
+	if f.TemplatePath == "" {
+		tmpl, err = template.New(filepath.Base(defaultTextTemplate)).Funcs(TemplateFuncs()).ParseFS(configs.Templates, defaultTextTemplate)
+	} else {
+		tmpl, err = template.New(filepath.Base(f.TemplatePath)).Funcs(TemplateFuncs()).ParseFiles(f.TemplatePath)
+	}
 	if err != nil {
 		return err
 	}
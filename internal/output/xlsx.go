@@ -0,0 +1,421 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// Cell styles, as indexes into the cellXfs list of xlsxStyles
+const (
+	styleDefault = iota
+	styleHeader
+	styleCurrency
+	styleUnitPrice
+	stylePercent
+)
+
+// xlsxCell is one cell of a worksheet; Value is a string, float64, int or nil
+type xlsxCell struct {
+	Value interface{}
+	Style int
+}
+
+// xlsxSheet is one worksheet of a workbook
+type xlsxSheet struct {
+	Name   string
+	Rows   [][]xlsxCell
+	Frozen bool // Keep the first row visible while scrolling
+}
+
+// XLSXFormatter formats reports as an Excel workbook with Summary, Resources
+// and, for comparisons, Diff sheets
+type XLSXFormatter struct{}
+
+// NewXLSXFormatter creates a new XLSX formatter
+func NewXLSXFormatter() *XLSXFormatter {
+	return &XLSXFormatter{}
+}
+
+// Format formats the report as an XLSX workbook
+func (f *XLSXFormatter) Format(report *model.Report, writer io.Writer) error {
+	sheets := []xlsxSheet{
+		summarySheet(report),
+		resourcesSheet(report),
+	}
+	if report.IsDiff {
+		sheets = append(sheets, diffSheet(report))
+	}
+
+	return writeWorkbook(writer, sheets)
+}
+
+// GetName returns the name of the formatter
+func (f *XLSXFormatter) GetName() string {
+	return "xlsx"
+}
+
+// summarySheet builds the sheet with totals and cost breakdowns
+func summarySheet(report *model.Report) xlsxSheet {
+	sheet := xlsxSheet{Name: "Summary"}
+
+	sheet.Rows = append(sheet.Rows,
+		[]xlsxCell{{"Cloud Cost Report", styleHeader}},
+		[]xlsxCell{{"Infrastructure Type", styleDefault}, {report.IaCFormat, styleDefault}},
+		[]xlsxCell{{"Generated", styleDefault}, {report.Timestamp.Format("2006-01-02 15:04:05 MST"), styleDefault}},
+		[]xlsxCell{{"Report ID", styleDefault}, {report.ReportID, styleDefault}},
+		nil,
+		[]xlsxCell{{"Hourly Cost", styleDefault}, {report.TotalHourly, styleUnitPrice}},
+		[]xlsxCell{{"Monthly Cost", styleDefault}, {report.TotalMonthly, styleCurrency}},
+		[]xlsxCell{{"Yearly Cost", styleDefault}, {report.TotalYearly, styleCurrency}},
+	)
+
+	if report.IsDiff {
+		sheet.Rows = append(sheet.Rows,
+			[]xlsxCell{{"Previous Monthly Cost", styleDefault}, {report.TotalMonthly - report.PriceDiff, styleCurrency}},
+			[]xlsxCell{{"Monthly Change", styleDefault}, {report.PriceDiff, styleCurrency}},
+			[]xlsxCell{{"Monthly Change %", styleDefault}, {report.PriceDiffPercent / 100, stylePercent}},
+		)
+	}
+
+	if unpriced := report.UnpricedCount(); unpriced > 0 {
+		sheet.Rows = append(sheet.Rows, []xlsxCell{{"Resources Not Priced", styleDefault}, {unpriced, styleDefault}})
+	}
+
+	breakdowns := []struct {
+		title     string
+		breakdown map[string]float64
+	}{
+		{"Provider", report.ByProvider},
+		{"Region", report.ByRegion},
+		{"Resource Type", report.ByResourceType},
+	}
+	for _, b := range breakdowns {
+		sheet.Rows = append(sheet.Rows, nil, breakdownHeader(b.title))
+		sheet.Rows = append(sheet.Rows, breakdownRows(b.breakdown, report.TotalMonthly)...)
+	}
+
+	tagKeys := make([]string, 0, len(report.ByTag))
+	for key := range report.ByTag {
+		tagKeys = append(tagKeys, key)
+	}
+	sort.Strings(tagKeys)
+	for _, key := range tagKeys {
+		sheet.Rows = append(sheet.Rows, nil, breakdownHeader("Tag: "+key))
+		sheet.Rows = append(sheet.Rows, breakdownRows(report.ByTag[key], report.TotalMonthly)...)
+	}
+
+	return sheet
+}
+
+// breakdownHeader returns the header row of a breakdown table
+func breakdownHeader(title string) []xlsxCell {
+	return []xlsxCell{
+		{title, styleHeader},
+		{"Monthly Cost", styleHeader},
+		{"Share", styleHeader},
+	}
+}
+
+// breakdownRows returns the rows of a breakdown table by descending cost
+func breakdownRows(breakdown map[string]float64, total float64) [][]xlsxCell {
+	var rows [][]xlsxCell
+	for _, bar := range chartBars(breakdown) {
+		share := 0.0
+		if total > 0 {
+			share = bar.Value / total
+		}
+		rows = append(rows, []xlsxCell{
+			{bar.Label, styleDefault},
+			{bar.Value, styleCurrency},
+			{share, stylePercent},
+		})
+	}
+	return rows
+}
+
+// resourcesSheet builds the sheet with one row per resource, or one row per
+// price component for resources that have them
+func resourcesSheet(report *model.Report) xlsxSheet {
+	sheet := xlsxSheet{Name: "Resources", Frozen: true}
+	tagKeys := collectTagKeys(report.Resources)
+
+	header := make([]xlsxCell, 0, len(csvColumns)+1+len(tagKeys))
+	for _, column := range csvColumns {
+		header = append(header, xlsxCell{column, styleHeader})
+	}
+	header = append(header, xlsxCell{"Not Priced", styleHeader})
+	for _, key := range tagKeys {
+		header = append(header, xlsxCell{"tag:" + key, styleHeader})
+	}
+	sheet.Rows = append(sheet.Rows, header)
+
+	for _, resource := range report.Resources {
+		var tail []xlsxCell
+		if resource.Unpriced != nil {
+			tail = append(tail, xlsxCell{resource.Unpriced.Message, styleDefault})
+		} else {
+			tail = append(tail, xlsxCell{})
+		}
+		for _, key := range tagKeys {
+			tail = append(tail, xlsxCell{resource.Tags[key], styleDefault})
+		}
+
+		var components []model.PriceComponent
+		if resource.PricingDetails != nil {
+			components = resource.PricingDetails.PriceComponents
+		}
+
+		if len(components) == 0 {
+			row := resourceCells(resource)
+			row = append(row,
+				xlsxCell{}, xlsxCell{}, xlsxCell{}, xlsxCell{},
+				xlsxCell{resource.HourlyPrice, styleUnitPrice},
+				xlsxCell{resource.MonthlyPrice, styleCurrency},
+				xlsxCell{resource.TotalHourly, styleUnitPrice},
+				xlsxCell{resource.TotalMonthly, styleCurrency},
+			)
+			sheet.Rows = append(sheet.Rows, append(row, tail...))
+			continue
+		}
+
+		// Component totals are monthly costs for a single instance
+		quantity := float64(resource.Quantity)
+		for _, component := range components {
+			row := resourceCells(resource)
+			row = append(row,
+				xlsxCell{component.Name, styleDefault},
+				xlsxCell{component.Unit, styleDefault},
+				xlsxCell{component.Units, styleDefault},
+				xlsxCell{component.UnitPrice, styleUnitPrice},
+				xlsxCell{component.Total / 730, styleUnitPrice},
+				xlsxCell{component.Total, styleCurrency},
+				xlsxCell{component.Total / 730 * quantity, styleUnitPrice},
+				xlsxCell{component.Total * quantity, styleCurrency},
+			)
+			sheet.Rows = append(sheet.Rows, append(row, tail...))
+		}
+	}
+
+	return sheet
+}
+
+// resourceCells returns the identifying cells of a resource row
+func resourceCells(resource model.Resource) []xlsxCell {
+	return []xlsxCell{
+		{resource.ID, styleDefault},
+		{resource.ResourceType, styleDefault},
+		{resource.Provider, styleDefault},
+		{resource.Region, styleDefault},
+		{resource.Size, styleDefault},
+		{resource.Quantity, styleDefault},
+	}
+}
+
+// diffSheet builds the sheet listing added, removed and changed resources
+func diffSheet(report *model.Report) xlsxSheet {
+	sheet := xlsxSheet{Name: "Diff", Frozen: true}
+	sheet.Rows = append(sheet.Rows, []xlsxCell{
+		{"Change", styleHeader},
+		{"Address", styleHeader},
+		{"Old Monthly Cost", styleHeader},
+		{"New Monthly Cost", styleHeader},
+		{"Delta", styleHeader},
+		{"Delta %", styleHeader},
+		{"Details", styleHeader},
+	})
+
+	for _, resource := range report.AddedResources {
+		sheet.Rows = append(sheet.Rows, []xlsxCell{
+			{"added", styleDefault},
+			{resource.ID, styleDefault},
+			{},
+			{resource.TotalMonthly, styleCurrency},
+			{resource.TotalMonthly, styleCurrency},
+			{},
+			{resource.Size, styleDefault},
+		})
+	}
+
+	for _, resource := range report.RemovedResources {
+		sheet.Rows = append(sheet.Rows, []xlsxCell{
+			{"removed", styleDefault},
+			{resource.ID, styleDefault},
+			{resource.TotalMonthly, styleCurrency},
+			{},
+			{-resource.TotalMonthly, styleCurrency},
+			{-1.0, stylePercent},
+			{resource.Size, styleDefault},
+		})
+	}
+
+	for _, diff := range report.ChangedResources {
+		var details string
+		for i, change := range diff.Changes {
+			if i > 0 {
+				details += "; "
+			}
+			details += fmt.Sprintf("%s: %s -> %s", change.Property, change.OldValue, change.NewValue)
+		}
+
+		oldMonthly := diff.OldResource.TotalMonthly
+		newMonthly := diff.NewResource.TotalMonthly
+		percent := xlsxCell{}
+		if oldMonthly != 0 {
+			percent = xlsxCell{(newMonthly - oldMonthly) / oldMonthly, stylePercent}
+		}
+
+		sheet.Rows = append(sheet.Rows, []xlsxCell{
+			{"changed", styleDefault},
+			{diff.ResourceID, styleDefault},
+			{oldMonthly, styleCurrency},
+			{newMonthly, styleCurrency},
+			{newMonthly - oldMonthly, styleCurrency},
+			percent,
+			{details, styleDefault},
+		})
+	}
+
+	return sheet
+}
+
+// writeWorkbook writes the sheets as an XLSX package
+func writeWorkbook(writer io.Writer, sheets []xlsxSheet) error {
+	z := zip.NewWriter(writer)
+
+	var contentTypes, workbook, workbookRels bytes.Buffer
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	contentTypes.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	contentTypes.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	contentTypes.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+
+	workbookRels.WriteString(xml.Header)
+	workbookRels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	for i, sheet := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.Name), n, n)
+		fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+	}
+	fmt.Fprintf(&workbookRels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, len(sheets)+1)
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	workbookRels.WriteString(`</Relationships>`)
+
+	parts := map[string][]byte{
+		"[Content_Types].xml":        contentTypes.Bytes(),
+		"_rels/.rels":                []byte(xlsxRootRels),
+		"xl/workbook.xml":            workbook.Bytes(),
+		"xl/_rels/workbook.xml.rels": workbookRels.Bytes(),
+		"xl/styles.xml":              []byte(xlsxStyles),
+	}
+	for i, sheet := range sheets {
+		parts[fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1)] = worksheetXML(sheet)
+	}
+
+	// Write parts in a stable order so identical reports produce identical files
+	names := make([]string, 0, len(parts))
+	for name := range parts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		w, err := z.Create(name)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(parts[name]); err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
+
+// worksheetXML renders the XML of one worksheet
+func worksheetXML(sheet xlsxSheet) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	if sheet.Frozen {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	}
+	b.WriteString(`<sheetFormatPr defaultColWidth="18" defaultRowHeight="15"/>`)
+	b.WriteString(`<sheetData>`)
+
+	for r, row := range sheet.Rows {
+		fmt.Fprintf(&b, `<row r="%d">`, r+1)
+		for c, cell := range row {
+			ref := columnName(c) + strconv.Itoa(r+1)
+			switch value := cell.Value.(type) {
+			case string:
+				fmt.Fprintf(&b, `<c r="%s" s="%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, cell.Style, xmlEscape(value))
+			case float64:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%s</v></c>`, ref, cell.Style, strconv.FormatFloat(value, 'g', -1, 64))
+			case int:
+				fmt.Fprintf(&b, `<c r="%s" s="%d"><v>%d</v></c>`, ref, cell.Style, value)
+			}
+		}
+		b.WriteString(`</row>`)
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.Bytes()
+}
+
+// columnName converts a zero-based column index to its letters (A, B, ..., AA)
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xmlEscape escapes text for use in XML content and attributes
+func xmlEscape(s string) string {
+	var b bytes.Buffer
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// xlsxRootRels is the package relationship pointing at the workbook
+const xlsxRootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the cell styles referenced by the style constants
+const xlsxStyles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="3">` +
+	`<numFmt numFmtId="164" formatCode="&quot;$&quot;#,##0.00"/>` +
+	`<numFmt numFmtId="165" formatCode="&quot;$&quot;#,##0.0000"/>` +
+	`<numFmt numFmtId="166" formatCode="0.0%"/>` +
+	`</numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="5">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="165" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="166" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
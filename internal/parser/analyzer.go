@@ -5,6 +5,7 @@ import (
 	"strings"
 
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/zclconf/go-cty/cty"
 )
 
@@ -13,16 +14,21 @@ type ResourceAnalyzer struct{}
 
 // FindSizeField looks for the best field representing size
 func (a *ResourceAnalyzer) FindSizeField(resourceType string, attrs hcl.Attributes) string {
+	// Volumes have no instance type; their type and capacity describe them
+	if resourceType == "aws_ebs_volume" {
+		return a.volumeSize(attrs)
+	}
+
 	// Common size field patterns by priority
 	sizePatterns := []struct {
 		suffix   string
 		priority int
 	}{
 		{"instance_type", 100},
+		{"instance_class", 95}, // Ahead of other _type attributes such as storage_type
 		{"_type", 90},
 		{"machine_type", 85},
 		{"size", 80},
-		{"instance_class", 75},
 		{"_class", 70},
 		{"_tier", 65},
 		{"_size", 60},
@@ -41,7 +47,8 @@ func (a *ResourceAnalyzer) FindSizeField(resourceType string, attrs hcl.Attribut
 			// Check exact match
 			if attrName == pattern.suffix || strings.HasSuffix(attrName, pattern.suffix) {
 				// Extract value directly from the attribute expression
-				if val, err := a.getExprStringValue(attr.Expr); err == nil && val != "" {
+				// Patterns are in priority order, so keep the first match
+				if val, err := a.getExprStringValue(attr.Expr); err == nil && val != "" && pattern.priority > candidates[val] {
 					candidates[val] = pattern.priority
 				}
 			}
@@ -62,7 +69,10 @@ func (a *ResourceAnalyzer) FindSizeField(resourceType string, attrs hcl.Attribut
 					strings.Contains(attrName, "class") {
 					priority = 75
 				}
-				candidates[val] = priority
+				// Never lower the priority given by the attribute name
+				if priority > candidates[val] {
+					candidates[val] = priority
+				}
 			}
 		}
 	}
@@ -81,6 +91,25 @@ func (a *ResourceAnalyzer) FindSizeField(resourceType string, attrs hcl.Attribut
 	return bestValue
 }
 
+// volumeSize describes an EBS volume by its type and size, such as
+// "gp3 100GiB". Volumes without a type are gp2.
+func (a *ResourceAnalyzer) volumeSize(attrs hcl.Attributes) string {
+	volumeType := "gp2"
+	if attr, ok := attrs["type"]; ok {
+		if val, err := a.getExprStringValue(attr.Expr); err == nil && val != "" {
+			volumeType = val
+		}
+	}
+
+	if attr, ok := attrs["size"]; ok {
+		value, diags := attr.Expr.Value(nil)
+		if !diags.HasErrors() && value.Type() == cty.Number && value.IsKnown() && !value.IsNull() {
+			return fmt.Sprintf("%s %sGiB", volumeType, value.AsBigFloat().Text('f', -1))
+		}
+	}
+	return volumeType
+}
+
 // looksLikeInstanceType determines if a string resembles a cloud instance type
 func (a *ResourceAnalyzer) looksLikeInstanceType(value string) bool {
 	// Common patterns for instance types across cloud providers:
@@ -232,12 +261,14 @@ func (a *ResourceAnalyzer) ExtractTags(attrs hcl.Attributes) map[string]string {
 	// Look for tags attribute
 	if attr, ok := attrs["tags"]; ok {
 		value, diags := attr.Expr.Value(nil)
-		if !diags.HasErrors() && value.Type().IsMapType() {
+		// Literal tag blocks evaluate to objects rather than maps
+		if !diags.HasErrors() && (value.Type().IsMapType() || value.Type().IsObjectType()) && value.IsWhollyKnown() && !value.IsNull() {
 			value.ForEachElement(func(key cty.Value, val cty.Value) bool {
 				if key.Type() == cty.String && val.Type() == cty.String {
 					tags[key.AsString()] = val.AsString()
 				}
-				return true
+				// Returning true would stop the iteration
+				return false
 			})
 		}
 	}
@@ -245,6 +276,93 @@ func (a *ResourceAnalyzer) ExtractTags(attrs hcl.Attributes) map[string]string {
 	return tags
 }
 
+// ExtractProperties extracts all attributes with literal values as plain Go values
+func (a *ResourceAnalyzer) ExtractProperties(attrs hcl.Attributes) map[string]interface{} {
+	properties := make(map[string]interface{})
+
+	for name, attr := range attrs {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			continue
+		}
+		if converted, ok := convertValue(value); ok {
+			properties[name] = converted
+		}
+	}
+
+	return properties
+}
+
+// ExtractBlocks extracts the nested blocks of a body, such as the
+// root_block_device of an instance, as lists of properties keyed by block
+// type. Each block holds its literal attributes and its own nested blocks.
+// Dynamic blocks are skipped.
+func (a *ResourceAnalyzer) ExtractBlocks(body hcl.Body) map[string]interface{} {
+	blocks := make(map[string]interface{})
+
+	syntaxBody, ok := body.(*hclsyntax.Body)
+	if !ok {
+		return blocks
+	}
+
+	for _, block := range syntaxBody.Blocks {
+		if block.Type == "dynamic" {
+			continue
+		}
+
+		// Nested blocks make JustAttributes report an error, but it still
+		// returns the attributes
+		attrs, _ := block.Body.JustAttributes()
+		properties := a.ExtractProperties(attrs)
+		for name, nested := range a.ExtractBlocks(block.Body) {
+			properties[name] = nested
+		}
+
+		list, _ := blocks[block.Type].([]interface{})
+		blocks[block.Type] = append(list, properties)
+	}
+
+	return blocks
+}
+
+// convertValue converts a cty value to a string, float64, bool, slice or map
+func convertValue(value cty.Value) (interface{}, bool) {
+	if value.IsNull() || !value.IsWhollyKnown() {
+		return nil, false
+	}
+
+	valueType := value.Type()
+	switch {
+	case valueType == cty.String:
+		return value.AsString(), true
+	case valueType == cty.Number:
+		f, _ := value.AsBigFloat().Float64()
+		return f, true
+	case valueType == cty.Bool:
+		return value.True(), true
+	case valueType.IsListType() || valueType.IsSetType() || valueType.IsTupleType():
+		items := make([]interface{}, 0, value.LengthInt())
+		for it := value.ElementIterator(); it.Next(); {
+			_, element := it.Element()
+			if converted, ok := convertValue(element); ok {
+				items = append(items, converted)
+			}
+		}
+		return items, true
+	case valueType.IsMapType() || valueType.IsObjectType():
+		fields := make(map[string]interface{})
+		for it := value.ElementIterator(); it.Next(); {
+			key, element := it.Element()
+			if converted, ok := convertValue(element); ok {
+				fields[key.AsString()] = converted
+			}
+		}
+		return fields, true
+	}
+
+	return nil, false
+}
+
 // getExprStringValue extracts a string value from an HCL expression
 func (a *ResourceAnalyzer) getExprStringValue(expr hcl.Expression) (string, error) {
 	value, diags := expr.Value(nil)
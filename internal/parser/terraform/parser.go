@@ -9,6 +9,7 @@ import (
 
 	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/littleworks-inc/cloudcost/internal/parser"
 	"github.com/littleworks-inc/cloudcost/pkg/model"
 	"github.com/zclconf/go-cty/cty"
@@ -97,6 +98,19 @@ func (p *Parser) Parse(path string) ([]model.Resource, error) {
 				resource.Name = resourceName
 				resource.ResourceType = resourceType
 
+				// Record where the whole block is defined
+				rng := block.DefRange
+				if body, ok := block.Body.(*hclsyntax.Body); ok {
+					rng = hcl.RangeOver(block.DefRange, body.SrcRange)
+				}
+				resource.Source = &model.SourceRange{
+					Filename:    rng.Filename,
+					StartLine:   rng.Start.Line,
+					StartColumn: rng.Start.Column,
+					EndLine:     rng.End.Line,
+					EndColumn:   rng.End.Column,
+				}
+
 				// Extract provider from resource type
 				parts := strings.Split(resourceType, "_")
 				if len(parts) > 0 {
@@ -118,6 +132,15 @@ func (p *Parser) Parse(path string) ([]model.Resource, error) {
 				// Extract quantity and tags
 				resource.Quantity = p.analyzer.FindQuantity(attrs)
 				resource.Tags = p.analyzer.ExtractTags(attrs)
+				resource.Properties = p.analyzer.ExtractProperties(attrs)
+
+				// Nested blocks, such as root_block_device, become lists of
+				// properties unless an attribute of the same name was set
+				for name, blocks := range p.analyzer.ExtractBlocks(block.Body) {
+					if _, ok := resource.Properties[name]; !ok {
+						resource.Properties[name] = blocks
+					}
+				}
 
 				// If some properties weren't determined, fall back to original method
 				if resource.Size == "" {
@@ -0,0 +1,240 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// Names of the checks
+const (
+	CheckMonthlyBudget              = "monthly_budget"
+	CheckMonthlyIncreaseBudget      = "monthly_increase_budget"
+	CheckMaxResourceMonthly         = "max_resource_monthly"
+	CheckMaxResourceIncrease        = "max_resource_increase"
+	CheckMaxResourceIncreasePercent = "max_resource_increase_percent"
+)
+
+// Config holds the limits checked against a report. A zero limit disables
+// its check.
+type Config struct {
+	MonthlyBudget              float64 // Total monthly cost
+	MonthlyIncreaseBudget      float64 // Total monthly increase (diff reports)
+	MaxResourceMonthly         float64 // Monthly cost of any one resource
+	MaxResourceIncrease        float64 // Monthly increase of any one resource (diff reports)
+	MaxResourceIncreasePercent float64 // Percentage increase of any one resource (diff reports)
+}
+
+// Checker evaluates the checks one resource at a time, so reports can be
+// checked without holding every resource in memory
+type Checker struct {
+	config          Config
+	mostExpensive   *model.Resource
+	resourceMonthly resourceCheck
+}
+
+// NewChecker creates a checker for the given limits
+func NewChecker(config Config) *Checker {
+	return &Checker{
+		config: config,
+		resourceMonthly: resourceCheck{
+			name:        CheckMaxResourceMonthly,
+			description: "No single resource costs more than the limit per month",
+			limit:       config.MaxResourceMonthly,
+			passMessage: fmt.Sprintf("No resource costs more than $%.2f per month", config.MaxResourceMonthly),
+		},
+	}
+}
+
+// Evaluate runs the enabled checks against a report and returns their results.
+// Checks on a single resource produce one failing result per offending
+// resource, or a single passing result when no resource breaches the limit.
+func Evaluate(report *model.Report, config Config) []model.PolicyResult {
+	checker := NewChecker(config)
+	for i := range report.Resources {
+		checker.Observe(&report.Resources[i])
+	}
+	return checker.Results(report)
+}
+
+// Observe checks a priced resource against the per-resource limits
+func (c *Checker) Observe(resource *model.Resource) {
+	if c.mostExpensive == nil || resource.TotalMonthly > c.mostExpensive.TotalMonthly {
+		largest := *resource
+		c.mostExpensive = &largest
+	}
+
+	if c.config.MaxResourceMonthly > 0 && resource.TotalMonthly > c.config.MaxResourceMonthly {
+		c.resourceMonthly.fail(resource, resource.TotalMonthly, fmt.Sprintf("%s costs $%.2f per month, more than the limit of $%.2f",
+			resource.ID, resource.TotalMonthly, c.config.MaxResourceMonthly))
+	}
+}
+
+// Results returns the results of all enabled checks, using the report totals
+// and the resources observed so far
+func (c *Checker) Results(report *model.Report) []model.PolicyResult {
+	config := c.config
+	var results []model.PolicyResult
+
+	if config.MonthlyBudget > 0 {
+		result := model.PolicyResult{
+			Check:       CheckMonthlyBudget,
+			Kind:        model.PolicyKindBudget,
+			Description: "Total monthly cost stays within the budget",
+			Passed:      report.TotalMonthly <= config.MonthlyBudget,
+			Actual:      report.TotalMonthly,
+			Limit:       config.MonthlyBudget,
+		}
+		if result.Passed {
+			result.Message = fmt.Sprintf("Estimated monthly cost $%.2f is within the budget of $%.2f", report.TotalMonthly, config.MonthlyBudget)
+		} else {
+			result.Message = fmt.Sprintf("Estimated monthly cost $%.2f exceeds the budget of $%.2f by $%.2f",
+				report.TotalMonthly, config.MonthlyBudget, report.TotalMonthly-config.MonthlyBudget)
+			pointAt(&result, c.mostExpensive)
+		}
+		results = append(results, result)
+	}
+
+	if config.MonthlyIncreaseBudget > 0 && report.IsDiff {
+		result := model.PolicyResult{
+			Check:       CheckMonthlyIncreaseBudget,
+			Kind:        model.PolicyKindBudget,
+			Description: "Total monthly cost increase stays within the budget",
+			Passed:      report.PriceDiff <= config.MonthlyIncreaseBudget,
+			Actual:      report.PriceDiff,
+			Limit:       config.MonthlyIncreaseBudget,
+		}
+		if result.Passed {
+			result.Message = fmt.Sprintf("Monthly cost change %+.2f is within the increase budget of $%.2f", report.PriceDiff, config.MonthlyIncreaseBudget)
+		} else {
+			result.Message = fmt.Sprintf("Monthly cost increase $%.2f exceeds the increase budget of $%.2f", report.PriceDiff, config.MonthlyIncreaseBudget)
+			increases := resourceIncreases(report)
+			if len(increases) > 0 {
+				largest := increases[0]
+				for _, increase := range increases[1:] {
+					if increase.amount > largest.amount {
+						largest = increase
+					}
+				}
+				pointAt(&result, largest.resource)
+			}
+		}
+		results = append(results, result)
+	}
+
+	if config.MaxResourceMonthly > 0 {
+		results = append(results, c.resourceMonthly.results()...)
+	}
+
+	if config.MaxResourceIncrease > 0 && report.IsDiff {
+		check := resourceCheck{
+			name:        CheckMaxResourceIncrease,
+			description: "No single resource increases in monthly cost by more than the limit",
+			limit:       config.MaxResourceIncrease,
+			passMessage: fmt.Sprintf("No resource increases by more than $%.2f per month", config.MaxResourceIncrease),
+		}
+		for _, increase := range resourceIncreases(report) {
+			if increase.amount > config.MaxResourceIncrease {
+				check.fail(increase.resource, increase.amount, fmt.Sprintf("%s increases by $%.2f per month ($%.2f -> $%.2f), more than the limit of $%.2f",
+					increase.resource.ID, increase.amount, increase.old, increase.resource.TotalMonthly, config.MaxResourceIncrease))
+			}
+		}
+		results = append(results, check.results()...)
+	}
+
+	if config.MaxResourceIncreasePercent > 0 && report.IsDiff {
+		check := resourceCheck{
+			name:        CheckMaxResourceIncreasePercent,
+			description: "No single resource increases in monthly cost by more than the percentage limit",
+			limit:       config.MaxResourceIncreasePercent,
+			passMessage: fmt.Sprintf("No resource increases by more than %.1f%% per month", config.MaxResourceIncreasePercent),
+		}
+		for _, increase := range resourceIncreases(report) {
+			// Added resources have no previous cost to compare against
+			if increase.old <= 0 {
+				continue
+			}
+			percent := increase.amount / increase.old * 100
+			if percent > config.MaxResourceIncreasePercent {
+				check.fail(increase.resource, percent, fmt.Sprintf("%s increases by %.1f%% per month ($%.2f -> $%.2f), more than the limit of %.1f%%",
+					increase.resource.ID, percent, increase.old, increase.resource.TotalMonthly, config.MaxResourceIncreasePercent))
+			}
+		}
+		results = append(results, check.results()...)
+	}
+
+	return results
+}
+
+// resourceCheck collects the results of a check applied to each resource
+type resourceCheck struct {
+	name        string
+	description string
+	limit       float64
+	passMessage string
+	failures    []model.PolicyResult
+}
+
+// fail records a resource that breaches the limit
+func (c *resourceCheck) fail(resource *model.Resource, actual float64, message string) {
+	result := model.PolicyResult{
+		Check:       c.name,
+		Kind:        model.PolicyKindPolicy,
+		Description: c.description,
+		Message:     message,
+		Actual:      actual,
+		Limit:       c.limit,
+	}
+	pointAt(&result, resource)
+	c.failures = append(c.failures, result)
+}
+
+// results returns the failures, or a single passing result
+func (c *resourceCheck) results() []model.PolicyResult {
+	if len(c.failures) > 0 {
+		return c.failures
+	}
+	return []model.PolicyResult{{
+		Check:       c.name,
+		Kind:        model.PolicyKindPolicy,
+		Description: c.description,
+		Passed:      true,
+		Message:     c.passMessage,
+		Limit:       c.limit,
+	}}
+}
+
+// increase is the monthly cost increase of one resource in a diff report
+type increase struct {
+	resource *model.Resource
+	old      float64
+	amount   float64
+}
+
+// resourceIncreases returns the added and changed resources whose monthly cost
+// went up
+func resourceIncreases(report *model.Report) []increase {
+	var increases []increase
+	for i := range report.AddedResources {
+		resource := &report.AddedResources[i]
+		if resource.TotalMonthly > 0 {
+			increases = append(increases, increase{resource: resource, amount: resource.TotalMonthly})
+		}
+	}
+	for _, diff := range report.ChangedResources {
+		amount := diff.NewResource.TotalMonthly - diff.OldResource.TotalMonthly
+		if amount > 0 {
+			increases = append(increases, increase{resource: diff.NewResource, old: diff.OldResource.TotalMonthly, amount: amount})
+		}
+	}
+	return increases
+}
+
+// pointAt attaches a resource and its location to a result
+func pointAt(result *model.PolicyResult, resource *model.Resource) {
+	if resource == nil {
+		return
+	}
+	result.ResourceID = resource.ID
+	result.Source = resource.Source
+}
@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/cache"
+)
+
+// productLister lists products from the AWS Pricing API. It is satisfied by
+// the SDK client and by the decorators wrapping it.
+type productLister interface {
+	GetProducts(ctx context.Context, params *awspricing.GetProductsInput, optFns ...func(*awspricing.Options)) (*awspricing.GetProductsOutput, error)
+}
+
+// cachedProducts is the part of a GetProducts response kept in the cache
+type cachedProducts struct {
+	PriceList []string `json:"price_list"`
+	NextToken *string  `json:"next_token,omitempty"`
+}
+
+// cachingLister answers GetProducts requests from a disk cache, calling the
+// API only for requests it has not seen within the cache TTL
+type cachingLister struct {
+	next     productLister
+	cache    *cache.Cache
+	endpoint string // Endpoint override, kept apart from the default endpoint
+}
+
+// GetProducts returns the cached response for the request, or fetches and
+// caches it. Failed requests are not cached.
+func (l *cachingLister) GetProducts(ctx context.Context, params *awspricing.GetProductsInput, optFns ...func(*awspricing.Options)) (*awspricing.GetProductsOutput, error) {
+	key := productsKey(params)
+	if l.endpoint != "" {
+		// Responses from another endpoint, such as a local stand-in, must
+		// never be served as real AWS prices
+		key = cache.Key(key, l.endpoint)
+	}
+
+	var cached cachedProducts
+	if l.cache.Get(key, &cached) {
+		return &awspricing.GetProductsOutput{
+			PriceList:     cached.PriceList,
+			NextToken:     cached.NextToken,
+			FormatVersion: aws.String("aws_v1"),
+		}, nil
+	}
+
+	output, err := l.next.GetProducts(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	// A cache that cannot be written only costs the next run an API call
+	if err := l.cache.Put(key, cachedProducts{PriceList: output.PriceList, NextToken: output.NextToken}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+
+	return output, nil
+}
+
+// productsKey identifies a GetProducts request by its service code and
+// filter set. Filters are sorted so their order does not matter.
+func productsKey(params *awspricing.GetProductsInput) string {
+	filters := make([]string, 0, len(params.Filters))
+	for _, filter := range params.Filters {
+		filters = append(filters, fmt.Sprintf("%q %q %q", string(filter.Type), aws.ToString(filter.Field), aws.ToString(filter.Value)))
+	}
+	sort.Strings(filters)
+
+	parts := []string{
+		"aws",
+		"GetProducts",
+		aws.ToString(params.ServiceCode),
+		strconv.Itoa(int(aws.ToInt32(params.MaxResults))),
+		aws.ToString(params.NextToken),
+	}
+	return cache.Key(append(parts, filters...)...)
+}
@@ -3,9 +3,10 @@ package aws
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
+	"os"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -13,46 +14,166 @@ import (
 	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
 	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
 	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/aws/offers"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/cache"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/ratelimit"
 	"github.com/littleworks-inc/cloudcost/pkg/model"
 )
 
 // Client implements the pricing.Client interface for AWS
 type Client struct {
 	pricingClient *awspricing.Client
+	products      productLister // Answers GetProducts, possibly from the cache
+	cache         *cache.Cache
+	indexDir      string             // Offer file index used instead of the API
+	endpoint      string             // Pricing API endpoint overriding the default
+	recordDir     string             // Directory to record API responses to
+	replayDir     string             // Directory to replay recorded API responses from
+	source        string             // Pricing source recorded on priced resources
+	limiter       *ratelimit.Limiter // Spaces out Pricing API requests
+	amiPlatforms  map[string]string  // Platform names by lowercase AMI ID
 	region        string
-	initialized   bool
-	error         error // Store initialization error
+	once          sync.Once // Initializes the client once, however many goroutines price resources
+	error         error     // Store initialization error
+}
+
+// Option configures an AWS pricing client
+type Option func(*Client)
+
+// WithCache answers repeated pricing requests from a disk cache. A nil cache
+// disables caching.
+func WithCache(c *cache.Cache) Option {
+	return func(client *Client) {
+		client.cache = c
+	}
+}
+
+// WithOfflineIndex prices resources from an index of AWS bulk offer files
+// written by offers.Import, without credentials or network access
+func WithOfflineIndex(dir string) Option {
+	return func(client *Client) {
+		client.indexDir = dir
+	}
+}
+
+// WithEndpoint sends Pricing API requests to another endpoint, such as a
+// local stand-in for tests
+func WithEndpoint(url string) Option {
+	return func(client *Client) {
+		client.endpoint = url
+	}
+}
+
+// WithRecording saves every pricing request and response to a directory, so
+// the run can be replayed with WithReplay
+func WithRecording(dir string) Option {
+	return func(client *Client) {
+		client.recordDir = dir
+	}
+}
+
+// WithReplay answers pricing requests from responses saved by WithRecording,
+// without credentials or network access. Requests that were not recorded fail.
+func WithReplay(dir string) Option {
+	return func(client *Client) {
+		client.replayDir = dir
+	}
+}
+
+// WithRateLimit limits Pricing API requests to rate per second. The limit
+// adapts: it drops when the API throttles and recovers as requests succeed.
+func WithRateLimit(rate float64) Option {
+	return func(client *Client) {
+		client.limiter = ratelimit.New(rate)
+	}
+}
+
+// WithAMIPlatforms prices instances launched from the given AMIs for a
+// platform other than Linux. It maps AMI IDs to names returned by Platforms,
+// such as "windows" or "rhel".
+func WithAMIPlatforms(platforms map[string]string) Option {
+	return func(client *Client) {
+		client.amiPlatforms = make(map[string]string, len(platforms))
+		for ami, platform := range platforms {
+			client.amiPlatforms[strings.ToLower(ami)] = platform
+		}
+	}
 }
 
 // NewClient creates a new AWS pricing client
-func NewClient() pricing.Client {
-	return &Client{
-		region:      "us-east-1", // Default region for queries (AWS Pricing API only available in us-east-1)
-		initialized: false,
+func NewClient(options ...Option) pricing.Client {
+	client := &Client{
+		source: "AWS Pricing API",
+		region: "us-east-1", // Default region for queries (AWS Pricing API only available in us-east-1)
+	}
+	for _, option := range options {
+		option(client)
 	}
+	if client.limiter == nil {
+		client.limiter = ratelimit.New(defaultRateLimit)
+	}
+	return client
 }
 
 // GetPrice retrieves the price for a specific resource
 func (c *Client) GetPrice(resource *model.Resource) error {
-	// Initialize client if needed
-	if !c.initialized {
-		if err := c.Initialize(); err != nil {
-			// Set prices to zero but preserve the error for reporting
+	// Resources that carry no charge need no pricing lookup
+	if pricing.IsFree("aws", resource.ResourceType) {
+		pricing.SetFree(resource)
+		return nil
+	}
+
+	// Set the region from the resource if available
+	region := resource.Region
+	if region == "" {
+		// Use a default region if none specified
+		region = "us-east-1"
+	}
+	region = zoneRegion(region)
+
+	// Determine service code and build appropriate filters based on resource type pattern
+	var usageOnly bool
+	query, ok := c.priceQuery(resource, region)
+	if !ok {
+		// Resource types priced entirely from usage estimates or volumes
+		if _, ok := usageComponents[resource.ResourceType]; ok || volumeResourceTypes[resource.ResourceType] {
+			usageOnly = true
+		} else {
+			// For unknown resource types
 			resource.HourlyPrice = 0
 			resource.MonthlyPrice = 0
 			resource.YearlyPrice = 0
+			return pricing.NewError(model.ReasonUnsupportedType, "unsupported resource type for pricing: %s", resource.ResourceType)
+		}
+	}
 
-			// Set pricing details with error information
-			resource.PricingDetails = &model.PricingDetails{
-				Currency:      "USD",
-				LastUpdated:   time.Now(),
-				PricingSource: "Error: " + err.Error(),
-			}
+	// Instance-based resources cannot be priced without a size
+	if !usageOnly && resource.Size == "" {
+		resource.HourlyPrice = 0
+		resource.MonthlyPrice = 0
+		resource.YearlyPrice = 0
+		return pricing.NewError(model.ReasonMissingSize, "no instance size found for resource: %s", resource.ID)
+	}
 
-			return fmt.Errorf("AWS pricing data unavailable: %v", err)
-		}
-	} else if c.error != nil {
-		// We tried to initialize before and failed
+	// Attributes such as an unknown database engine cannot be priced
+	if query.err != nil {
+		resource.HourlyPrice = 0
+		resource.MonthlyPrice = 0
+		resource.YearlyPrice = 0
+		return query.err
+	}
+
+	// Resources priced only from usage cost nothing until usage is given
+	if usageOnly && !hasPricedComponents(resource) {
+		resource.HourlyPrice = 0
+		resource.MonthlyPrice = 0
+		resource.YearlyPrice = 0
+		return nil
+	}
+
+	// Initialize client if needed
+	if err := c.Initialize(); err != nil {
+		// Set prices to zero but preserve the error for reporting
 		resource.HourlyPrice = 0
 		resource.MonthlyPrice = 0
 		resource.YearlyPrice = 0
@@ -61,69 +182,26 @@ func (c *Client) GetPrice(resource *model.Resource) error {
 		resource.PricingDetails = &model.PricingDetails{
 			Currency:      "USD",
 			LastUpdated:   time.Now(),
-			PricingSource: "Error: " + c.error.Error(),
+			PricingSource: "Error: " + err.Error(),
 		}
 
-		return fmt.Errorf("AWS pricing data unavailable: %v", c.error)
+		return pricing.NewError(model.ReasonAPIError, "AWS pricing data unavailable: %v", err)
 	}
 
-	fmt.Printf("Fetching price for: %s (%s) in region %s\n",
+	fmt.Fprintf(os.Stderr, "Fetching price for: %s (%s) in region %s\n",
 		resource.ResourceType, resource.Size, resource.Region)
 
-	// Set the region from the resource if available
-	region := resource.Region
-	if region == "" {
-		// Use a default region if none specified
-		region = "us-east-1"
-	}
-
-	// Determine service code and build appropriate filters based on resource type pattern
-	var serviceCode string
-	var filters []types.Filter
-
-	// Determine service based on resource type pattern
-	switch {
-	case strings.HasPrefix(resource.ResourceType, "aws_instance"):
-		serviceCode = "AmazonEC2"
-		filters = buildEC2Filters(resource.Size, region)
-	case strings.HasPrefix(resource.ResourceType, "aws_db_instance"):
-		serviceCode = "AmazonRDS"
-		filters = buildRDSFilters(resource.Size, region)
-	case strings.HasPrefix(resource.ResourceType, "aws_elasticache"):
-		serviceCode = "AmazonElastiCache"
-		filters = buildElastiCacheFilters(resource.Size, region)
-	default:
-		// For unknown resource types
+	if usageOnly {
 		resource.HourlyPrice = 0
 		resource.MonthlyPrice = 0
 		resource.YearlyPrice = 0
-		return fmt.Errorf("unsupported resource type for pricing: %s", resource.ResourceType)
+		return c.addUsageComponents(resource, region)
 	}
 
-	fmt.Printf("Using service code: %s with %d filters\n", serviceCode, len(filters))
+	fmt.Fprintf(os.Stderr, "Using service code: %s with %d filters\n", query.serviceCode, len(query.filters))
 
 	// Call the AWS pricing API with a retry mechanism
-	var response *awspricing.GetProductsOutput
-	var err error
-
-	for attempt := 1; attempt <= 3; attempt++ {
-		response, err = c.pricingClient.GetProducts(context.TODO(), &awspricing.GetProductsInput{
-			Filters:     filters,
-			MaxResults:  aws.Int32(100),
-			ServiceCode: aws.String(serviceCode),
-		})
-
-		if err == nil {
-			break
-		}
-
-		fmt.Printf("Attempt %d failed: %v\n", attempt, err)
-		if attempt < 3 {
-			// Wait before retrying
-			time.Sleep(time.Duration(attempt) * 500 * time.Millisecond)
-		}
-	}
-
+	response, err := c.getProducts(query.serviceCode, query.filters)
 	if err != nil {
 		// If API call fails, set prices to zero and return error
 		resource.HourlyPrice = 0
@@ -137,374 +215,131 @@ func (c *Client) GetPrice(resource *model.Resource) error {
 			PricingSource: "Error: " + err.Error(),
 		}
 
-		return fmt.Errorf("failed to get pricing data: %v", err)
+		return pricing.NewError(model.ReasonAPIError, "failed to get pricing data: %v", err)
 	}
 
-	fmt.Printf("Got %d pricing results\n", len(response.PriceList))
+	fmt.Fprintf(os.Stderr, "Got %d pricing results\n", len(response.PriceList))
 
-	// Process the pricing data
-	if len(response.PriceList) > 0 {
-		// Initialize pricing details
-		resource.PricingDetails = &model.PricingDetails{
-			Currency:      "USD",
-			LastUpdated:   time.Now(),
-			PricingSource: "AWS Pricing API",
-		}
+	// Pick the product matching the filters
+	product, err := selectProduct(resource, query.serviceCode, response)
+	if err != nil {
+		resource.HourlyPrice = 0
+		resource.MonthlyPrice = 0
+		resource.YearlyPrice = 0
+		return err
+	}
 
-		// Try a few results until we find one with pricing
-		var priceFound bool
-
-		for i, priceListItem := range response.PriceList {
-			if i >= 5 { // Limit to first 5 results to avoid excessive processing
-				break
-			}
-
-			// Parse the price data
-			var priceData map[string]interface{}
-			if err := json.Unmarshal([]byte(priceListItem), &priceData); err != nil {
-				fmt.Printf("Failed to parse pricing data: %v\n", err)
-				continue
-			}
-
-			// Extract product details for logging
-			product, hasProduct := priceData["product"].(map[string]interface{})
-			if hasProduct {
-				attributes, hasAttrs := product["attributes"].(map[string]interface{})
-				if hasAttrs {
-					if instanceType, ok := attributes["instanceType"].(string); ok {
-						fmt.Printf("Result %d is for instance type: %s\n", i, instanceType)
-					}
-				}
-			}
-
-			// Extract on-demand pricing
-			terms, ok := priceData["terms"].(map[string]interface{})
-			if !ok {
-				fmt.Printf("Result %d: Invalid pricing terms structure\n", i)
-				continue
-			}
-
-			onDemand, ok := terms["OnDemand"].(map[string]interface{})
-			if !ok {
-				fmt.Printf("Result %d: No on-demand pricing available\n", i)
-				continue
-			}
-
-			// Try to find a valid price
-			for _, priceData := range onDemand {
-				priceDimensions, ok := priceData.(map[string]interface{})["priceDimensions"].(map[string]interface{})
-				if !ok {
-					continue
-				}
-
-				for _, dimension := range priceDimensions {
-					dimensionData, ok := dimension.(map[string]interface{})
-					if !ok {
-						continue
-					}
-
-					pricePerUnit, ok := dimensionData["pricePerUnit"].(map[string]interface{})
-					if !ok {
-						continue
-					}
-
-					usdPrice, ok := pricePerUnit["USD"].(string)
-					if !ok {
-						continue
-					}
-
-					// Parse the price as float
-					var hourlyPrice float64
-					if _, err := fmt.Sscanf(usdPrice, "%f", &hourlyPrice); err != nil {
-						fmt.Printf("Failed to parse price '%s': %v\n", usdPrice, err)
-						continue
-					}
-
-					// Found a price!
-					fmt.Printf("Found price: $%f/hour\n", hourlyPrice)
-					resource.HourlyPrice = hourlyPrice
-					resource.MonthlyPrice = hourlyPrice * 730 // Average hours per month
-					resource.YearlyPrice = hourlyPrice * 8760 // Hours per year
-					priceFound = true
-
-					// Special handling for t3.micro with $0.00 price
-					if resource.Size == "t3.micro" && resource.HourlyPrice == 0 {
-						fmt.Printf("Special case: t3.micro shows $0.00 price, applying relative pricing calculation\n")
-
-						// Find t3.small price for comparison
-						t3SmallFilters := []types.Filter{
-							{
-								Field: aws.String("ServiceCode"),
-								Type:  types.FilterTypeTermMatch,
-								Value: aws.String("AmazonEC2"),
-							},
-							{
-								Field: aws.String("instanceType"),
-								Type:  types.FilterTypeTermMatch,
-								Value: aws.String("t3.small"),
-							},
-							{
-								Field: aws.String("operatingSystem"),
-								Type:  types.FilterTypeTermMatch,
-								Value: aws.String("Linux"),
-							},
-						}
-
-						// Call the AWS pricing API
-						t3SmallResponse, err := c.pricingClient.GetProducts(context.TODO(), &awspricing.GetProductsInput{
-							Filters:     t3SmallFilters,
-							MaxResults:  aws.Int32(10),
-							ServiceCode: aws.String("AmazonEC2"),
-						})
-
-						if err == nil && len(t3SmallResponse.PriceList) > 0 {
-							// Find t3.small price
-							var t3SmallPrice float64
-							var smallPriceFound bool
-
-							// Process each result
-							for _, priceListItem := range t3SmallResponse.PriceList {
-								var priceData map[string]interface{}
-								if err := json.Unmarshal([]byte(priceListItem), &priceData); err != nil {
-									continue
-								}
-
-								// Extract terms
-								terms, ok := priceData["terms"].(map[string]interface{})
-								if !ok {
-									continue
-								}
-
-								onDemand, ok := terms["OnDemand"].(map[string]interface{})
-								if !ok {
-									continue
-								}
-
-								// Process on-demand pricing
-								for _, priceData := range onDemand {
-									priceObj, ok := priceData.(map[string]interface{})
-									if !ok {
-										continue
-									}
-
-									priceDimensions, ok := priceObj["priceDimensions"].(map[string]interface{})
-									if !ok {
-										continue
-									}
-
-									// Process each price dimension
-									for _, dimension := range priceDimensions {
-										dimensionData, ok := dimension.(map[string]interface{})
-										if !ok {
-											continue
-										}
-
-										pricePerUnit, ok := dimensionData["pricePerUnit"].(map[string]interface{})
-										if !ok {
-											continue
-										}
-
-										usdPrice, ok := pricePerUnit["USD"].(string)
-										if !ok {
-											continue
-										}
-
-										// Parse price
-										if _, err := fmt.Sscanf(usdPrice, "%f", &t3SmallPrice); err == nil && t3SmallPrice > 0 {
-											fmt.Printf("Found t3.small price: $%f/hour\n", t3SmallPrice)
-											smallPriceFound = true
-											break
-										}
-									}
-
-									if smallPriceFound {
-										break
-									}
-								}
-
-								if smallPriceFound {
-									break
-								}
-							}
-
-							if smallPriceFound && t3SmallPrice > 0 {
-								// t3.micro is approximately half the price of t3.small
-								// This is based on the fact that t3.micro has half the vCPUs and memory
-								estimatedPrice := t3SmallPrice * 0.5
-								fmt.Printf("Calculating t3.micro price as 50%% of t3.small: $%f/hour\n", estimatedPrice)
-
-								resource.HourlyPrice = estimatedPrice
-								resource.MonthlyPrice = estimatedPrice * 730
-								resource.YearlyPrice = estimatedPrice * 8760
-
-								resource.PricingDetails.PricingSource = "Estimated based on t3.small pricing (50% ratio)"
-							}
-						}
-					}
-
-					break
-				}
-				if priceFound {
-					break
-				}
-			}
-			if priceFound {
-				break
-			}
-		}
+	fmt.Fprintf(os.Stderr, "Found price: $%f/hour (SKU %s)\n", product.Price, product.SKU)
+	resource.HourlyPrice = product.Price
+	resource.MonthlyPrice = product.Price * 730 // Average hours per month
+	resource.YearlyPrice = product.Price * 8760 // Hours per year
+	resource.PricingDetails = &model.PricingDetails{
+		Currency:      "USD",
+		LastUpdated:   time.Now(),
+		PricingSource: c.source,
+		MetaData:      map[string]string{"sku": product.SKU},
+	}
+	for name, value := range query.metadata {
+		resource.PricingDetails.MetaData[name] = value
+	}
 
-		if !priceFound {
-			fmt.Printf("Could not find valid pricing in any of the results\n")
-			resource.HourlyPrice = 0
-			resource.MonthlyPrice = 0
-			resource.YearlyPrice = 0
-			return fmt.Errorf("could not extract price from API response")
+	// Add usage-driven components such as data transfer
+	return c.addUsageComponents(resource, region)
+}
+
+// getProducts calls the AWS pricing API, which retries and de-duplicates
+// requests as configured in Initialize
+func (c *Client) getProducts(serviceCode string, filters []types.Filter) (*awspricing.GetProductsOutput, error) {
+	return c.products.GetProducts(context.TODO(), &awspricing.GetProductsInput{
+		Filters:     filters,
+		MaxResults:  aws.Int32(100),
+		ServiceCode: aws.String(serviceCode),
+	})
+}
+
+// maxProductPages caps the pages of products read by getAllProducts
+const maxProductPages = 20
+
+// getAllProducts returns the price list items of every page of products
+// matching the filters, and whether pages beyond maxProductPages were left
+// unread
+func (c *Client) getAllProducts(serviceCode string, filters []types.Filter) ([]string, bool, error) {
+	input := &awspricing.GetProductsInput{
+		Filters:     filters,
+		MaxResults:  aws.Int32(100),
+		ServiceCode: aws.String(serviceCode),
+	}
+
+	var priceList []string
+	for page := 0; page < maxProductPages; page++ {
+		response, err := c.products.GetProducts(context.TODO(), input)
+		if err != nil {
+			return nil, false, err
 		}
-	} else {
-		// Try with fewer filters
-		if len(filters) > 2 {
-			fmt.Printf("No results with specific filters, trying with fewer filters\n")
-
-			// Simplified filters - just service code and instance type
-			simplifiedFilters := []types.Filter{
-				{
-					Field: aws.String("ServiceCode"),
-					Type:  types.FilterTypeTermMatch,
-					Value: aws.String(serviceCode),
-				},
-			}
-
-			if resource.Size != "" {
-				// The field name might be different depending on the service
-				var fieldName string
-				switch serviceCode {
-				case "AmazonEC2":
-					fieldName = "instanceType"
-				case "AmazonRDS":
-					fieldName = "instanceType"
-				case "AmazonElastiCache":
-					fieldName = "cacheNodeType"
-				default:
-					fieldName = "instanceType"
-				}
-
-				simplifiedFilters = append(simplifiedFilters, types.Filter{
-					Field: aws.String(fieldName),
-					Type:  types.FilterTypeTermMatch,
-					Value: aws.String(resource.Size),
-				})
-			}
-
-			fmt.Printf("Trying simplified filters: %+v\n", simplifiedFilters)
-
-			simplifiedResponse, err := c.pricingClient.GetProducts(context.TODO(), &awspricing.GetProductsInput{
-				Filters:     simplifiedFilters,
-				MaxResults:  aws.Int32(100),
-				ServiceCode: aws.String(serviceCode),
-			})
-
-			if err == nil && len(simplifiedResponse.PriceList) > 0 {
-				fmt.Printf("Got %d results with simplified filters\n", len(simplifiedResponse.PriceList))
-
-				// Process the simplified response
-				var priceFound bool
-				for i, priceListItem := range simplifiedResponse.PriceList {
-					if i >= 5 { // Limit to first 5 results
-						break
-					}
-
-					var priceData map[string]interface{}
-					if err := json.Unmarshal([]byte(priceListItem), &priceData); err != nil {
-						continue
-					}
-
-					// Extract product details for logging
-					product, hasProduct := priceData["product"].(map[string]interface{})
-					if hasProduct {
-						attributes, hasAttrs := product["attributes"].(map[string]interface{})
-						if hasAttrs {
-							if instanceType, ok := attributes["instanceType"].(string); ok {
-								fmt.Printf("Simplified result %d is for instance type: %s\n", i, instanceType)
-							}
-						}
-					}
-
-					// Extract terms
-					if terms, ok := priceData["terms"].(map[string]interface{}); ok {
-						if onDemand, ok := terms["OnDemand"].(map[string]interface{}); ok {
-							for _, priceData := range onDemand {
-								if priceDimensions, ok := priceData.(map[string]interface{})["priceDimensions"].(map[string]interface{}); ok {
-									for _, dimension := range priceDimensions {
-										if dimensionData, ok := dimension.(map[string]interface{}); ok {
-											if pricePerUnit, ok := dimensionData["pricePerUnit"].(map[string]interface{}); ok {
-												if usdPrice, ok := pricePerUnit["USD"].(string); ok {
-													var hourlyPrice float64
-													if _, err := fmt.Sscanf(usdPrice, "%f", &hourlyPrice); err == nil {
-														fmt.Printf("Found price with simplified filters: $%f/hour\n", hourlyPrice)
-
-														// Only use non-zero prices
-														if hourlyPrice > 0 {
-															// Initialize pricing details if needed
-															if resource.PricingDetails == nil {
-																resource.PricingDetails = &model.PricingDetails{
-																	Currency:      "USD",
-																	LastUpdated:   time.Now(),
-																	PricingSource: "AWS Pricing API (simplified query)",
-																}
-															}
-
-															resource.HourlyPrice = hourlyPrice
-															resource.MonthlyPrice = hourlyPrice * 730
-															resource.YearlyPrice = hourlyPrice * 8760
-															priceFound = true
-															break
-														}
-													}
-												}
-											}
-										}
-									}
-									if priceFound {
-										break
-									}
-								}
-							}
-						}
-					}
-
-					if priceFound {
-						break
-					}
-				}
-
-				if !priceFound {
-					fmt.Printf("Could not find valid pricing in simplified results\n")
-					resource.HourlyPrice = 0
-					resource.MonthlyPrice = 0
-					resource.YearlyPrice = 0
-					return fmt.Errorf("no valid pricing found for resource: %s", resource.ID)
-				}
-			} else {
-				fmt.Printf("Still no results with simplified filters\n")
-				resource.HourlyPrice = 0
-				resource.MonthlyPrice = 0
-				resource.YearlyPrice = 0
-				return fmt.Errorf("no pricing data found for resource: %s", resource.ID)
-			}
-		} else {
-			// If no pricing data found and we've already tried simplified filters
-			resource.HourlyPrice = 0
-			resource.MonthlyPrice = 0
-			resource.YearlyPrice = 0
-			return fmt.Errorf("no pricing data found for resource: %s", resource.ID)
+		priceList = append(priceList, response.PriceList...)
+		if response.NextToken == nil {
+			return priceList, false, nil
 		}
+		input.NextToken = response.NextToken
 	}
+	return priceList, true, nil
+}
+
+// zoneRegion returns the region of an availability zone such as us-east-1a,
+// or the region itself
+func zoneRegion(region string) string {
+	n := len(region)
+	if n >= 2 && region[n-1] >= 'a' && region[n-1] <= 'z' && region[n-2] >= '0' && region[n-2] <= '9' {
+		return region[:n-1]
+	}
+	return region
+}
 
-	return nil
+// productQuery is the Pricing API lookup of a resource priced by the hour
+type productQuery struct {
+	serviceCode string
+	filters     []types.Filter
+	metadata    map[string]string // Values detected for the filters, recorded in the pricing details
+	err         error             // Why the resource's attributes cannot be priced
+}
+
+// priceQuery determines the service code and filters for resources priced by the hour
+func (c *Client) priceQuery(resource *model.Resource, region string) (productQuery, bool) {
+	// Determine service based on resource type
+	switch resource.ResourceType {
+	case "aws_instance":
+		placement := c.detectEC2Placement(resource)
+		return productQuery{"AmazonEC2", buildEC2Filters(resource.Size, region, placement), placement.metadata(), nil}, true
+	case "aws_db_instance":
+		database, err := detectRDSDatabase(resource)
+		return productQuery{"AmazonRDS", buildRDSFilters(resource.Size, region, database), database.metadata(), err}, true
+	case "aws_elasticache_cluster", "aws_elasticache_replication_group":
+		return productQuery{"AmazonElastiCache", buildElastiCacheFilters(resource.Size, region), nil, nil}, true
+	}
+
+	return productQuery{}, false
+}
+
+// Coverage returns how the client handles a resource type
+func (c *Client) Coverage(resourceType string) pricing.Coverage {
+	// Free types are checked first, as GetPrice does
+	if pricing.IsFree("aws", resourceType) {
+		return pricing.CoverageFree
+	}
+	if _, ok := c.priceQuery(&model.Resource{ResourceType: resourceType}, ""); ok {
+		return pricing.CoveragePriced
+	}
+	if volumeResourceTypes[resourceType] {
+		return pricing.CoveragePriced
+	}
+	if _, ok := usageComponents[resourceType]; ok {
+		return pricing.CoverageUsageBased
+	}
+	return pricing.CoverageUnsupported
 }
 
 // Helper functions to build filters for different services
-func buildEC2Filters(instanceType, region string) []types.Filter {
+func buildEC2Filters(instanceType, region string, placement ec2Placement) []types.Filter {
 	filters := []types.Filter{
 		{
 			Field: aws.String("ServiceCode"),
@@ -531,23 +366,22 @@ func buildEC2Filters(instanceType, region string) []types.Filter {
 		})
 	}
 
-	// Add additional common filters for EC2
-	filters = append(filters, types.Filter{
-		Field: aws.String("operatingSystem"),
-		Type:  types.FilterTypeTermMatch,
-		Value: aws.String("Linux"),
-	})
-
-	filters = append(filters, types.Filter{
-		Field: aws.String("tenancy"),
-		Type:  types.FilterTypeTermMatch,
-		Value: aws.String("Shared"),
-	})
+	// Pin the product down to the on-demand price of the instance's platform.
+	// Without capacitystatus the $0 capacity reservation SKUs match too, and
+	// without preInstalledSw and licenseModel so do SQL Server and
+	// bring-your-own-license SKUs.
+	filters = append(filters,
+		termMatch("operatingSystem", placement.OperatingSystem),
+		termMatch("tenancy", placement.Tenancy),
+		termMatch("capacitystatus", "Used"),
+		termMatch("preInstalledSw", placement.PreInstalledSw),
+		termMatch("licenseModel", placement.LicenseModel),
+	)
 
 	return filters
 }
 
-func buildRDSFilters(instanceType, region string) []types.Filter {
+func buildRDSFilters(instanceType, region string, database rdsDatabase) []types.Filter {
 	filters := []types.Filter{
 		{
 			Field: aws.String("ServiceCode"),
@@ -574,12 +408,16 @@ func buildRDSFilters(instanceType, region string) []types.Filter {
 		})
 	}
 
-	// Add database engine filter (default to MySQL)
-	filters = append(filters, types.Filter{
-		Field: aws.String("databaseEngine"),
-		Type:  types.FilterTypeTermMatch,
-		Value: aws.String("MySQL"),
-	})
+	// Pin the product down to the engine, edition, license and deployment
+	filters = append(filters,
+		termMatch("productFamily", "Database Instance"),
+		termMatch("databaseEngine", database.DatabaseEngine),
+		termMatch("licenseModel", database.LicenseModel),
+		termMatch("deploymentOption", database.DeploymentOption),
+	)
+	if database.DatabaseEdition != "" {
+		filters = append(filters, termMatch("databaseEdition", database.DatabaseEdition))
+	}
 
 	return filters
 }
@@ -619,22 +457,65 @@ func (c *Client) GetName() string {
 	return "AWS"
 }
 
-// Initialize sets up the pricing client
+// Initialize sets up the pricing client. It is safe to call from several
+// goroutines; only the first call does the work, and later calls return its
+// error.
 func (c *Client) Initialize() error {
-	// Mark as initialized to avoid repeated initialization attempts
-	c.initialized = true
+	c.once.Do(func() {
+		products, err := c.newProductLister()
+		if err != nil {
+			c.error = err
+			return
+		}
+
+		// Record responses from whichever source answers them
+		if c.recordDir != "" {
+			products = &recordingLister{next: products, dir: c.recordDir}
+		}
+
+		// Fetch identical lookups only once, however many resources need them
+		c.products = newDedupLister(products)
+	})
+
+	return c.error
+}
+
+// newProductLister creates the source of price lists: recorded responses, an
+// offer file index or the Pricing API
+func (c *Client) newProductLister() (productLister, error) {
+	// Replayed responses need neither credentials nor the API
+	if c.replayDir != "" {
+		if _, err := os.Stat(c.replayDir); err != nil {
+			return nil, fmt.Errorf("failed to open pricing recording: %v", err)
+		}
+		return &replayingLister{dir: c.replayDir}, nil
+	}
+
+	// Offline pricing needs neither credentials nor the API
+	if c.indexDir != "" {
+		index, err := offers.Open(c.indexDir)
+		if err != nil {
+			return nil, err
+		}
+		c.source = "AWS Price List offer files"
+		return newOfflineLister(index), nil
+	}
 
 	// Load AWS SDK configuration
 	cfg, err := config.LoadDefaultConfig(context.TODO(),
 		config.WithRegion("us-east-1"), // Pricing API is only available in us-east-1
 	)
 	if err != nil {
-		c.error = fmt.Errorf("AWS credentials not found: %v", err)
-		return c.error
+		return nil, fmt.Errorf("AWS credentials not found: %v", err)
 	}
 
-	// Create pricing client
-	c.pricingClient = awspricing.NewFromConfig(cfg)
+	// Create pricing client, leaving retries to throttledLister
+	c.pricingClient = awspricing.NewFromConfig(cfg, func(options *awspricing.Options) {
+		if c.endpoint != "" {
+			options.BaseEndpoint = aws.String(c.endpoint)
+		}
+		options.Retryer = aws.NopRetryer{}
+	})
 
 	// Test the client with a simple API call
 	_, err = c.pricingClient.DescribeServices(context.TODO(), &awspricing.DescribeServicesInput{
@@ -642,9 +523,12 @@ func (c *Client) Initialize() error {
 	})
 
 	if err != nil {
-		c.error = fmt.Errorf("AWS API access failed: %v", err)
-		return c.error
+		return nil, fmt.Errorf("AWS API access failed: %v", err)
 	}
 
-	return nil
+	var products productLister = &throttledLister{next: c.pricingClient, limiter: c.limiter}
+	if c.cache != nil {
+		products = &cachingLister{next: products, cache: c.cache, endpoint: c.endpoint}
+	}
+	return products, nil
 }
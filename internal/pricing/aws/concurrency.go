@@ -0,0 +1,119 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/ratelimit"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/singleflight"
+)
+
+// Retry limits for Pricing API requests
+const (
+	maxAttempts         = 3 // Attempts for retryable errors
+	maxThrottleAttempts = 8 // Attempts when the API throttles
+	retryBase           = 500 * time.Millisecond
+	retryCeiling        = 20 * time.Second
+)
+
+// defaultRateLimit is the default number of Pricing API requests per second
+const defaultRateLimit = 10
+
+// dedupLister fetches each distinct GetProducts request once per run.
+// Concurrent identical requests wait for the first one; later ones reuse its
+// response. Failed requests are not remembered.
+type dedupLister struct {
+	next      productLister
+	group     singleflight.Group
+	mu        sync.Mutex
+	responses map[string]*awspricing.GetProductsOutput
+}
+
+// newDedupLister creates a de-duplicating product lister
+func newDedupLister(next productLister) *dedupLister {
+	return &dedupLister{next: next, responses: make(map[string]*awspricing.GetProductsOutput)}
+}
+
+// GetProducts returns the response to an identical earlier request, or
+// fetches it
+func (l *dedupLister) GetProducts(ctx context.Context, params *awspricing.GetProductsInput, optFns ...func(*awspricing.Options)) (*awspricing.GetProductsOutput, error) {
+	key := productsKey(params)
+
+	response, err := l.group.Do(key, func() (interface{}, error) {
+		l.mu.Lock()
+		output, ok := l.responses[key]
+		l.mu.Unlock()
+		if ok {
+			return output, nil
+		}
+
+		output, err := l.next.GetProducts(ctx, params, optFns...)
+		if err != nil {
+			return nil, err
+		}
+
+		l.mu.Lock()
+		l.responses[key] = output
+		l.mu.Unlock()
+		return output, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return response.(*awspricing.GetProductsOutput), nil
+}
+
+// throttledLister sends GetProducts requests at the rate its limiter allows
+// and retries failures with exponential backoff and jitter, slowing the
+// limiter down whenever the API throttles
+type throttledLister struct {
+	next    productLister
+	limiter *ratelimit.Limiter
+}
+
+// GetProducts sends the request, retrying throttled and transient failures
+func (l *throttledLister) GetProducts(ctx context.Context, params *awspricing.GetProductsInput, optFns ...func(*awspricing.Options)) (*awspricing.GetProductsOutput, error) {
+	throttles := retry.IsErrorThrottles(retry.DefaultThrottles)
+	retryables := retry.IsErrorRetryables(retry.DefaultRetryables)
+
+	for attempt := 1; ; attempt++ {
+		if err := l.limiter.Wait(ctx); err != nil {
+			return nil, err
+		}
+
+		output, err := l.next.GetProducts(ctx, params, optFns...)
+		if err == nil {
+			l.limiter.Succeeded()
+			return output, nil
+		}
+
+		throttled := throttles.IsErrorThrottle(err) == aws.TrueTernary
+		if throttled {
+			l.limiter.Throttled()
+		}
+
+		switch {
+		case throttled && attempt < maxThrottleAttempts:
+		case !throttled && retryables.IsErrorRetryable(err) == aws.TrueTernary && attempt < maxAttempts:
+		default:
+			return nil, err
+		}
+
+		delay := ratelimit.Backoff(attempt, retryBase, retryCeiling)
+		fmt.Fprintf(os.Stderr, "Attempt %d failed: %v (retrying in %s)\n", attempt, err, delay.Round(time.Millisecond))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// Performance included in the price of gp3 storage
+const (
+	gp3BaselineIOPS       = 3000
+	gp3BaselineThroughput = 125 // MiB/s
+)
+
+// defaultBlockDeviceSize is the size assumed for block devices without a
+// volume_size, which take the size of their AMI or snapshot
+const defaultBlockDeviceSize = 8
+
+// ebsVolumeTypes are the EBS volume types that can be priced
+var ebsVolumeTypes = map[string]bool{
+	"standard": true, // Magnetic
+	"gp2":      true,
+	"gp3":      true,
+	"io1":      true,
+	"io2":      true,
+	"st1":      true,
+	"sc1":      true,
+}
+
+// io2IOPSTiers are the io2 IOPS price tiers, each a product of its own
+// identified by the end of its usage type
+var io2IOPSTiers = []struct {
+	usageType  string
+	start, end float64 // IOPS covered by the tier; an end of 0 means no limit
+}{
+	{"EBS:VolumeP-IOPS.io2", 0, 32000},
+	{"EBS:VolumeP-IOPS.io2.tier2", 32000, 64000},
+	{"EBS:VolumeP-IOPS.io2.tier3", 64000, 0},
+}
+
+// volumeResourceTypes are the resource types priced entirely from their EBS
+// volumes
+var volumeResourceTypes = map[string]bool{
+	"aws_ebs_volume":           true,
+	"aws_launch_configuration": true,
+	"aws_launch_template":      true,
+}
+
+// componentPricers price the components of a resource that follow from its
+// attributes, such as its EBS volumes, by resource type
+var componentPricers = map[string]func(c *Client, resource *model.Resource, region string) ([]model.PriceComponent, error){
+	"aws_db_instance":          (*Client).priceRDSStorage,
+	"aws_ebs_volume":           (*Client).priceEBSVolumes,
+	"aws_instance":             (*Client).priceEBSVolumes,
+	"aws_launch_configuration": (*Client).priceEBSVolumes,
+	"aws_launch_template":      (*Client).priceEBSVolumes,
+}
+
+// ebsVolume is an EBS volume, priced as a resource of its own or as a block
+// device of an instance or launch template
+type ebsVolume struct {
+	label      string // Names the block device in price components
+	volumeType string
+	size       float64 // GiB
+	iops       float64
+	throughput float64 // MiB/s
+}
+
+// ebsVolumes returns the EBS volumes a resource is charged for
+func ebsVolumes(resource *model.Resource) []ebsVolume {
+	switch resource.ResourceType {
+	case "aws_ebs_volume":
+		return []ebsVolume{newEBSVolume("", resource.Properties, "type", "size", 0)}
+	case "aws_instance", "aws_launch_configuration":
+		var volumes []ebsVolume
+		for _, block := range propertyBlocks(resource.Properties, "root_block_device") {
+			volumes = append(volumes, newEBSVolume("Root volume", block, "volume_type", "volume_size", defaultBlockDeviceSize))
+		}
+		for i, block := range propertyBlocks(resource.Properties, "ebs_block_device") {
+			volumes = append(volumes, newEBSVolume(deviceLabel(block, i), block, "volume_type", "volume_size", defaultBlockDeviceSize))
+		}
+		return volumes
+	case "aws_launch_template":
+		var volumes []ebsVolume
+		for i, mapping := range propertyBlocks(resource.Properties, "block_device_mappings") {
+			for _, block := range propertyBlocks(mapping, "ebs") {
+				volumes = append(volumes, newEBSVolume(deviceLabel(mapping, i), block, "volume_type", "volume_size", defaultBlockDeviceSize))
+			}
+		}
+		return volumes
+	}
+	return nil
+}
+
+// newEBSVolume reads a volume from the properties of a resource or block
+func newEBSVolume(label string, properties map[string]interface{}, typeName, sizeName string, defaultSize float64) ebsVolume {
+	volume := ebsVolume{
+		label:      label,
+		volumeType: "gp2",
+		size:       blockNumber(properties, sizeName, defaultSize),
+		iops:       blockNumber(properties, "iops", 0),
+		throughput: blockNumber(properties, "throughput", 0),
+	}
+	if volumeType, ok := properties[typeName].(string); ok && volumeType != "" {
+		volume.volumeType = volumeType
+	}
+
+	// gp3 includes a baseline and is charged for performance above it
+	if volume.volumeType == "gp3" {
+		volume.iops = max(volume.iops, gp3BaselineIOPS)
+		volume.throughput = max(volume.throughput, gp3BaselineThroughput)
+	}
+	return volume
+}
+
+// deviceLabel names the i-th block device by its device name
+func deviceLabel(block map[string]interface{}, i int) string {
+	if name, ok := block["device_name"].(string); ok && name != "" {
+		return "Volume " + name
+	}
+	return fmt.Sprintf("Volume %d", i+1)
+}
+
+// priceEBSVolumes prices the EBS volumes of a resource. Block devices that
+// cannot be priced are left out with a warning, unless the API failed.
+func (c *Client) priceEBSVolumes(resource *model.Resource, region string) ([]model.PriceComponent, error) {
+	var components []model.PriceComponent
+	for _, volume := range ebsVolumes(resource) {
+		volumeComponents, err := c.priceEBSVolume(resource, volume, region)
+		if err != nil {
+			if resource.ResourceType == "aws_ebs_volume" || pricing.ReasonFor(err) == model.ReasonAPIError {
+				return nil, err
+			}
+			resource.Warnings = append(resource.Warnings, fmt.Sprintf("%s not priced: %v", volume.label, err))
+			continue
+		}
+		components = append(components, volumeComponents...)
+	}
+	return components, nil
+}
+
+// priceEBSVolume prices the storage, IOPS and throughput of a volume
+func (c *Client) priceEBSVolume(resource *model.Resource, volume ebsVolume, region string) ([]model.PriceComponent, error) {
+	if !ebsVolumeTypes[volume.volumeType] {
+		return nil, pricing.NewError(model.ReasonUnsupportedType, "unsupported EBS volume type %q for resource: %s", volume.volumeType, resource.ID)
+	}
+	if volume.size <= 0 {
+		return nil, pricing.NewError(model.ReasonMissingSize, "no volume size found for resource: %s", resource.ID)
+	}
+
+	storage, err := c.ebsProduct(region, "Storage", volume.volumeType, "")
+	if err != nil {
+		return nil, err
+	}
+	components := []model.PriceComponent{
+		volumeComponent(volume, fmt.Sprintf("Storage (%s)", volume.volumeType), "GB-Mo", storage.Price, volume.size),
+	}
+
+	switch volume.volumeType {
+	case "gp3":
+		if volume.iops > gp3BaselineIOPS {
+			iops, err := c.ebsProduct(region, "System Operation", "gp3", "")
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, volumeComponent(volume, "Provisioned IOPS (gp3)", "IOPS-Mo", iops.Price, volume.iops-gp3BaselineIOPS))
+		}
+		if volume.throughput > gp3BaselineThroughput {
+			throughput, err := c.ebsProduct(region, "Provisioned Throughput", "gp3", "")
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, volumeComponent(volume, "Provisioned throughput (gp3)", "MiBps-Mo", throughput.Price, volume.throughput-gp3BaselineThroughput))
+		}
+	case "io1":
+		if volume.iops > 0 {
+			iops, err := c.ebsProduct(region, "System Operation", "io1", "")
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, volumeComponent(volume, "Provisioned IOPS (io1)", "IOPS-Mo", iops.Price, volume.iops))
+		}
+	case "io2":
+		for i, tier := range io2IOPSTiers {
+			if volume.iops <= tier.start {
+				break
+			}
+			units := volume.iops - tier.start
+			if tier.end > 0 {
+				units = min(volume.iops, tier.end) - tier.start
+			}
+
+			iops, err := c.ebsProduct(region, "System Operation", "io2", tier.usageType)
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, volumeComponent(volume, fmt.Sprintf("Provisioned IOPS (io2, tier %d)", i+1), "IOPS-Mo", iops.Price, units))
+		}
+	}
+
+	return components, nil
+}
+
+// ebsProduct looks up the one EBS product of a product family and volume
+// type in a region. Products sharing both are told apart by the end of
+// their usage type, which is prefixed with the region outside us-east-1.
+func (c *Client) ebsProduct(region, productFamily, volumeType, usageType string) (pricedProduct, error) {
+	what := fmt.Sprintf("%s %s in %s", volumeType, productFamily, region)
+	return c.uniqueProduct("AmazonEC2", buildEBSFilters(region, productFamily, volumeType), what, func(product pricedProduct) bool {
+		return usageType == "" || strings.HasSuffix(product.Attributes["usagetype"], usageType)
+	})
+}
+
+// buildEBSFilters builds the filters for an EBS product
+func buildEBSFilters(region, productFamily, volumeType string) []types.Filter {
+	return []types.Filter{
+		termMatch("regionCode", region),
+		termMatch("productFamily", productFamily),
+		termMatch("volumeApiName", volumeType),
+	}
+}
+
+// volumeComponent builds a monthly price component of a volume, named after
+// its block device
+func volumeComponent(volume ebsVolume, name, unit string, unitPrice, units float64) model.PriceComponent {
+	if volume.label != "" {
+		name = volume.label + ": " + name
+	}
+	return monthlyComponent(name, unit, unitPrice, units)
+}
+
+// propertyBlocks returns the nested blocks of a type from resource or block
+// properties
+func propertyBlocks(properties map[string]interface{}, name string) []map[string]interface{} {
+	list, _ := properties[name].([]interface{})
+	var blocks []map[string]interface{}
+	for _, item := range list {
+		if block, ok := item.(map[string]interface{}); ok {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// blockNumber returns a numeric property of a resource or block, or a
+// default value
+func blockNumber(properties map[string]interface{}, name string, defaultValue float64) float64 {
+	if value, ok := properties[name].(float64); ok {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,440 @@
+package offers
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// csvTermColumns are the columns of CSV offer files that describe terms and
+// prices rather than product attributes
+var csvTermColumns = map[string]bool{
+	"SKU":                 true,
+	"OfferTermCode":       true,
+	"RateCode":            true,
+	"TermType":            true,
+	"PriceDescription":    true,
+	"EffectiveDate":       true,
+	"StartingRange":       true,
+	"EndingRange":         true,
+	"Unit":                true,
+	"PricePerUnit":        true,
+	"Currency":            true,
+	"RelatedTo":           true,
+	"LeaseContractLength": true,
+	"PurchaseOption":      true,
+	"OfferingClass":       true,
+	"Product Family":      true,
+}
+
+// ErrNotOffer is returned for JSON files that are not offer files, such as
+// the offer and region index files published alongside them
+var ErrNotOffer = errors.New("not an offer file")
+
+// ImportResult describes the index written for one service
+type ImportResult struct {
+	ServiceCode string
+	Files       []string
+	Products    int
+}
+
+// ImportSummary describes an import
+type ImportSummary struct {
+	Services []ImportResult
+	Skipped  []string // Files that are not offer files
+}
+
+// Import reads AWS bulk offer files (JSON or CSV, for one service and
+// region each or for all regions) and writes an index file per service to
+// the index directory, replacing earlier imports of the same services.
+// Only on-demand terms are kept.
+func Import(paths []string, indexDir string) (*ImportSummary, error) {
+	summary := &ImportSummary{}
+	offers := make(map[string]*Offer)
+	products := make(map[string]map[string]*Product)
+	files := make(map[string][]string)
+
+	for _, path := range paths {
+		offer, err := ReadOfferFile(path)
+		if err == ErrNotOffer {
+			summary.Skipped = append(summary.Skipped, path)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Offer files for several regions of a service merge into one index
+		merged, ok := offers[offer.ServiceCode]
+		if !ok {
+			merged = &Offer{Version: indexVersion, ServiceCode: offer.ServiceCode}
+			offers[offer.ServiceCode] = merged
+			products[offer.ServiceCode] = make(map[string]*Product)
+		}
+		if offer.PublicationDate > merged.PublicationDate {
+			merged.PublicationDate = offer.PublicationDate
+		}
+		for _, product := range offer.Products {
+			products[offer.ServiceCode][product.SKU] = product
+		}
+		files[offer.ServiceCode] = append(files[offer.ServiceCode], path)
+	}
+
+	if err := os.MkdirAll(indexDir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create pricing index: %v", err)
+	}
+
+	for serviceCode, offer := range offers {
+		for _, product := range products[serviceCode] {
+			offer.Products = append(offer.Products, product)
+		}
+		sortProducts(offer.Products)
+
+		if err := writeIndexFile(indexPath(indexDir, serviceCode), offer); err != nil {
+			return nil, err
+		}
+		summary.Services = append(summary.Services, ImportResult{
+			ServiceCode: serviceCode,
+			Files:       files[serviceCode],
+			Products:    len(offer.Products),
+		})
+	}
+
+	sort.Slice(summary.Services, func(i, j int) bool {
+		return summary.Services[i].ServiceCode < summary.Services[j].ServiceCode
+	})
+	return summary, nil
+}
+
+// FindOfferFiles returns the JSON and CSV files in a directory tree
+func FindOfferFiles(dir string) ([]string, error) {
+	var paths []string
+	err := filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.IsDir() {
+			return nil
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json", ".csv":
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find offer files: %v", err)
+	}
+	return paths, nil
+}
+
+// ReadOfferFile reads an AWS bulk offer file in JSON or CSV format. It
+// returns ErrNotOffer for files without an offer code.
+func ReadOfferFile(path string) (*Offer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offer file: %v", err)
+	}
+	defer file.Close()
+
+	var offer *Offer
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		offer, err = readJSONOffer(bufio.NewReader(file))
+	case ".csv":
+		offer, err = readCSVOffer(bufio.NewReader(file))
+	default:
+		return nil, fmt.Errorf("unsupported offer file %s: expected .json or .csv", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read offer file %s: %v", path, err)
+	}
+	if offer.ServiceCode == "" {
+		return nil, ErrNotOffer
+	}
+	return offer, nil
+}
+
+// readJSONOffer reads a JSON offer file one product and term at a time, so
+// the reserved terms of large files are never held in memory
+func readJSONOffer(r io.Reader) (*Offer, error) {
+	decoder := json.NewDecoder(r)
+	offer := &Offer{Version: indexVersion}
+	products := make(map[string]*Product)
+	onDemand := make(map[string]map[string]*Term)
+
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+	for decoder.More() {
+		key, err := readKey(decoder)
+		if err != nil {
+			return nil, err
+		}
+
+		switch key {
+		case "offerCode":
+			err = decoder.Decode(&offer.ServiceCode)
+		case "publicationDate":
+			err = decoder.Decode(&offer.PublicationDate)
+		case "products":
+			err = readObject(decoder, func(sku string) error {
+				var product Product
+				if err := decoder.Decode(&product); err != nil {
+					return err
+				}
+				products[sku] = &product
+				return nil
+			})
+		case "terms":
+			err = readObject(decoder, func(termType string) error {
+				return readObject(decoder, func(sku string) error {
+					if termType != "OnDemand" {
+						return skipValue(decoder)
+					}
+					var terms map[string]*Term
+					if err := decoder.Decode(&terms); err != nil {
+						return err
+					}
+					onDemand[sku] = terms
+					return nil
+				})
+			})
+		default:
+			err = skipValue(decoder)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for sku, product := range products {
+		product.OnDemand = onDemand[sku]
+		offer.Products = append(offer.Products, product)
+	}
+	sortProducts(offer.Products)
+	return offer, nil
+}
+
+// readCSVOffer reads a CSV offer file: metadata rows such as "OfferCode",
+// followed by a header row and one row per price dimension
+func readCSVOffer(r io.Reader) (*Offer, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.ReuseRecord = true
+
+	offer := &Offer{Version: indexVersion}
+	products := make(map[string]*Product)
+	var header []string
+	column := make(map[string]int)
+
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Metadata rows come before the header
+		if header == nil {
+			switch strings.TrimPrefix(record[0], "\ufeff") {
+			case "OfferCode":
+				if len(record) > 1 {
+					offer.ServiceCode = record[1]
+				}
+			case "Publication Date":
+				if len(record) > 1 {
+					offer.PublicationDate = record[1]
+				}
+			case "SKU":
+				header = append([]string(nil), record...)
+				header[0] = strings.TrimPrefix(header[0], "\ufeff")
+				for i, name := range header {
+					column[name] = i
+				}
+			}
+			continue
+		}
+
+		value := func(name string) string {
+			if i, ok := column[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+
+		sku := value("SKU")
+		product, ok := products[sku]
+		if !ok {
+			product = &Product{SKU: sku, ProductFamily: value("Product Family"), Attributes: make(map[string]string)}
+			for i, name := range header {
+				if !csvTermColumns[name] && i < len(record) && record[i] != "" {
+					product.Attributes[attributeName(name)] = record[i]
+				}
+			}
+			products[sku] = product
+		}
+
+		if value("TermType") != "OnDemand" {
+			continue
+		}
+
+		termKey := sku + "." + value("OfferTermCode")
+		if product.OnDemand == nil {
+			product.OnDemand = make(map[string]*Term)
+		}
+		term, ok := product.OnDemand[termKey]
+		if !ok {
+			term = &Term{
+				OfferTermCode:   value("OfferTermCode"),
+				SKU:             sku,
+				EffectiveDate:   value("EffectiveDate"),
+				PriceDimensions: make(map[string]*PriceDimension),
+				TermAttributes:  map[string]string{},
+			}
+			product.OnDemand[termKey] = term
+		}
+
+		dimension := &PriceDimension{
+			RateCode:     value("RateCode"),
+			Description:  value("PriceDescription"),
+			BeginRange:   value("StartingRange"),
+			EndRange:     value("EndingRange"),
+			Unit:         value("Unit"),
+			PricePerUnit: map[string]string{value("Currency"): value("PricePerUnit")},
+			AppliesTo:    []string{},
+		}
+		if dimension.BeginRange == "" {
+			dimension.BeginRange = "0"
+		}
+		if dimension.EndRange == "" {
+			dimension.EndRange = "Inf"
+		}
+		term.PriceDimensions[dimension.RateCode] = dimension
+	}
+
+	if header == nil {
+		return nil, fmt.Errorf("no header row found")
+	}
+
+	for _, product := range products {
+		offer.Products = append(offer.Products, product)
+	}
+	sortProducts(offer.Products)
+	return offer, nil
+}
+
+// writeIndexFile writes an offer as compressed JSON, through a temporary
+// file so a running estimate never reads a partial index
+func writeIndexFile(path string, offer *Offer) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write pricing index: %v", err)
+	}
+	defer os.Remove(temp.Name())
+
+	writer := gzip.NewWriter(temp)
+	if err := json.NewEncoder(writer).Encode(offer); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write pricing index: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write pricing index: %v", err)
+	}
+	if err := temp.Chmod(0o644); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write pricing index: %v", err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write pricing index: %v", err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write pricing index: %v", err)
+	}
+	return nil
+}
+
+// attributeName converts a CSV column header into the camel-case attribute
+// name used by JSON offer files, e.g. "Instance Type" to "instanceType"
+func attributeName(header string) string {
+	var b strings.Builder
+	for i, word := range strings.Fields(header) {
+		var letters []rune
+		for _, r := range word {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) {
+				letters = append(letters, r)
+			}
+		}
+		if len(letters) == 0 {
+			continue
+		}
+		if i == 0 {
+			letters[0] = unicode.ToLower(letters[0])
+			b.WriteString(string(letters))
+			continue
+		}
+		b.WriteRune(unicode.ToUpper(letters[0]))
+		b.WriteString(strings.ToLower(string(letters[1:])))
+	}
+	return b.String()
+}
+
+// expectDelim reads a JSON delimiter token
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %v, found %v", delim, token)
+	}
+	return nil
+}
+
+// readKey reads an object key token
+func readKey(decoder *json.Decoder) (string, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return "", err
+	}
+	key, ok := token.(string)
+	if !ok {
+		return "", fmt.Errorf("expected an object key, found %v", token)
+	}
+	return key, nil
+}
+
+// readObject calls fn for each key of a JSON object; fn must consume the value
+func readObject(decoder *json.Decoder, fn func(key string) error) error {
+	if err := expectDelim(decoder, '{'); err != nil {
+		return err
+	}
+	for decoder.More() {
+		key, err := readKey(decoder)
+		if err != nil {
+			return err
+		}
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return expectDelim(decoder, '}')
+}
+
+// skipValue consumes the next JSON value without decoding it
+func skipValue(decoder *json.Decoder) error {
+	var value json.RawMessage
+	return decoder.Decode(&value)
+}
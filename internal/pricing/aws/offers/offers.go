@@ -0,0 +1,295 @@
+package offers
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// indexVersion is the version of the index file format
+const indexVersion = 1
+
+// indexSuffix is the file extension of a service's index file
+const indexSuffix = ".json.gz"
+
+// Offer holds the products of one service, as read from AWS bulk offer files
+type Offer struct {
+	Version         int        `json:"version"`
+	ServiceCode     string     `json:"service_code"`
+	PublicationDate string     `json:"publication_date,omitempty"`
+	Products        []*Product `json:"products"`
+}
+
+// Product is a product with its on-demand terms. The JSON names match the
+// AWS offer files and Pricing API responses.
+type Product struct {
+	SKU           string            `json:"sku"`
+	ProductFamily string            `json:"productFamily,omitempty"`
+	Attributes    map[string]string `json:"attributes"`
+	OnDemand      map[string]*Term  `json:"onDemand,omitempty"` // Keyed by SKU and offer term code
+}
+
+// Term is an offer term with its price dimensions
+type Term struct {
+	OfferTermCode   string                     `json:"offerTermCode"`
+	SKU             string                     `json:"sku"`
+	EffectiveDate   string                     `json:"effectiveDate"`
+	PriceDimensions map[string]*PriceDimension `json:"priceDimensions"`
+	TermAttributes  map[string]string          `json:"termAttributes"`
+}
+
+// PriceDimension is one price of a term, possibly limited to a usage range
+type PriceDimension struct {
+	RateCode     string            `json:"rateCode"`
+	Description  string            `json:"description"`
+	BeginRange   string            `json:"beginRange"`
+	EndRange     string            `json:"endRange"`
+	Unit         string            `json:"unit"`
+	PricePerUnit map[string]string `json:"pricePerUnit"`
+	AppliesTo    []string          `json:"appliesTo"`
+}
+
+// Filter matches products whose attribute equals a value. Like TERM_MATCH
+// filters of the AWS Pricing API, field and value are compared ignoring case.
+type Filter struct {
+	Field string
+	Value string
+}
+
+// Index answers product queries from an index directory written by Import.
+// Each service is loaded on its first query.
+type Index struct {
+	dir    string
+	mu     sync.Mutex
+	offers map[string]*indexedOffer
+}
+
+// indexedOffer is a loaded offer with the products for each attribute value
+type indexedOffer struct {
+	offer    *Offer
+	postings map[string]map[string][]int // Normalized field -> lower-case value -> product positions
+}
+
+// Open opens an index directory
+func Open(dir string) (*Index, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pricing index: %v", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("pricing index %s is not a directory", dir)
+	}
+	return &Index{dir: dir, offers: make(map[string]*indexedOffer)}, nil
+}
+
+// Dir returns the index directory
+func (ix *Index) Dir() string {
+	return ix.dir
+}
+
+// Services returns the service codes in the index
+func (ix *Index) Services() ([]string, error) {
+	entries, err := os.ReadDir(ix.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing index: %v", err)
+	}
+
+	var services []string
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), indexSuffix) {
+			services = append(services, strings.TrimSuffix(entry.Name(), indexSuffix))
+		}
+	}
+	return services, nil
+}
+
+// Has reports whether the index contains a service
+func (ix *Index) Has(serviceCode string) bool {
+	_, err := os.Stat(indexPath(ix.dir, serviceCode))
+	return err == nil
+}
+
+// Offer returns the offer of a service
+func (ix *Index) Offer(serviceCode string) (*Offer, error) {
+	indexed, err := ix.load(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+	return indexed.offer, nil
+}
+
+// Query returns the products of a service matching every filter, in SKU order
+func (ix *Index) Query(serviceCode string, filters []Filter) ([]*Product, error) {
+	indexed, err := ix.load(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(filters) == 0 {
+		return indexed.offer.Products, nil
+	}
+
+	// Intersect the sorted product positions of each filter
+	var positions []int
+	for i, filter := range filters {
+		matches := indexed.postings[normalizeField(filter.Field)][strings.ToLower(filter.Value)]
+		if i == 0 {
+			positions = matches
+		} else {
+			positions = intersect(positions, matches)
+		}
+		if len(positions) == 0 {
+			return nil, nil
+		}
+	}
+
+	products := make([]*Product, len(positions))
+	for i, position := range positions {
+		products[i] = indexed.offer.Products[position]
+	}
+	return products, nil
+}
+
+// PriceListItem returns the product in the format of a Pricing API price
+// list entry
+func (p *Product) PriceListItem(offer *Offer) (string, error) {
+	item := struct {
+		Product struct {
+			SKU           string            `json:"sku"`
+			ProductFamily string            `json:"productFamily,omitempty"`
+			Attributes    map[string]string `json:"attributes"`
+		} `json:"product"`
+		ServiceCode     string `json:"serviceCode"`
+		PublicationDate string `json:"publicationDate,omitempty"`
+		Terms           struct {
+			OnDemand map[string]*Term `json:"OnDemand,omitempty"`
+		} `json:"terms"`
+	}{
+		ServiceCode:     offer.ServiceCode,
+		PublicationDate: offer.PublicationDate,
+	}
+	item.Product.SKU = p.SKU
+	item.Product.ProductFamily = p.ProductFamily
+	item.Product.Attributes = p.Attributes
+	item.Terms.OnDemand = p.OnDemand
+
+	content, err := json.Marshal(item)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode product %s: %v", p.SKU, err)
+	}
+	return string(content), nil
+}
+
+// load reads and indexes a service's offer once
+func (ix *Index) load(serviceCode string) (*indexedOffer, error) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+
+	if indexed, ok := ix.offers[serviceCode]; ok {
+		return indexed, nil
+	}
+
+	offer, err := readIndexFile(indexPath(ix.dir, serviceCode))
+	if err != nil {
+		return nil, err
+	}
+
+	indexed := &indexedOffer{offer: offer, postings: make(map[string]map[string][]int)}
+	add := func(field, value string, position int) {
+		field = normalizeField(field)
+		if indexed.postings[field] == nil {
+			indexed.postings[field] = make(map[string][]int)
+		}
+		value = strings.ToLower(value)
+		indexed.postings[field][value] = append(indexed.postings[field][value], position)
+	}
+	for position, product := range offer.Products {
+		add("sku", product.SKU, position)
+		if product.ProductFamily != "" {
+			add("productFamily", product.ProductFamily, position)
+		}
+		for field, value := range product.Attributes {
+			add(field, value, position)
+		}
+	}
+
+	ix.offers[serviceCode] = indexed
+	return indexed, nil
+}
+
+// readIndexFile reads a service's index file
+func readIndexFile(path string) (*Offer, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no offer file imported for %s", strings.TrimSuffix(filepath.Base(path), indexSuffix))
+		}
+		return nil, fmt.Errorf("failed to read pricing index: %v", err)
+	}
+	defer file.Close()
+
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pricing index %s: %v", path, err)
+	}
+	defer reader.Close()
+
+	var offer Offer
+	if err := json.NewDecoder(reader).Decode(&offer); err != nil {
+		return nil, fmt.Errorf("failed to read pricing index %s: %v", path, err)
+	}
+	if offer.Version != indexVersion {
+		return nil, fmt.Errorf("pricing index %s has version %d, expected %d; run 'cloudcost pricing import' again",
+			path, offer.Version, indexVersion)
+	}
+	return &offer, nil
+}
+
+// indexPath returns the index file of a service
+func indexPath(dir, serviceCode string) string {
+	return filepath.Join(dir, serviceCode+indexSuffix)
+}
+
+// normalizeField reduces an attribute name to lower-case letters and digits,
+// so names from JSON offer files ("preInstalledSw") and CSV headers
+// ("Pre Installed S/W") match
+func normalizeField(field string) string {
+	var b strings.Builder
+	for _, r := range field {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+		}
+	}
+	return b.String()
+}
+
+// intersect returns the positions in both sorted lists
+func intersect(a, b []int) []int {
+	var result []int
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		switch {
+		case a[i] < b[j]:
+			i++
+		case a[i] > b[j]:
+			j++
+		default:
+			result = append(result, a[i])
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// sortProducts orders products by SKU
+func sortProducts(products []*Product) {
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].SKU < products[j].SKU
+	})
+}
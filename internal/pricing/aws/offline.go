@@ -0,0 +1,96 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/aws/offers"
+)
+
+// defaultMaxResults is the page size of the Pricing API when none is given
+const defaultMaxResults = 100
+
+// offlineLister answers GetProducts requests from a local index of AWS bulk
+// offer files, in the same format as the Pricing API
+type offlineLister struct {
+	index   *offers.Index
+	mu      sync.Mutex
+	missing map[string]bool // Services already reported as not imported
+}
+
+// newOfflineLister creates a product lister for an index directory
+func newOfflineLister(index *offers.Index) *offlineLister {
+	return &offlineLister{index: index, missing: make(map[string]bool)}
+}
+
+// GetProducts returns the products matching the TERM_MATCH filters of the
+// request, paginated by MaxResults and NextToken
+func (l *offlineLister) GetProducts(ctx context.Context, params *awspricing.GetProductsInput, optFns ...func(*awspricing.Options)) (*awspricing.GetProductsOutput, error) {
+	serviceCode := aws.ToString(params.ServiceCode)
+
+	// Services without an offer file match nothing, like unknown filters do
+	if !l.index.Has(serviceCode) {
+		l.mu.Lock()
+		if !l.missing[serviceCode] {
+			l.missing[serviceCode] = true
+			fmt.Fprintf(os.Stderr, "Warning: no offer file imported for %s; run 'cloudcost pricing import' with its offer file\n", serviceCode)
+		}
+		l.mu.Unlock()
+		return &awspricing.GetProductsOutput{PriceList: []string{}, FormatVersion: aws.String("aws_v1")}, nil
+	}
+
+	var filters []offers.Filter
+	for _, filter := range params.Filters {
+		if filter.Type != types.FilterTypeTermMatch {
+			return nil, fmt.Errorf("unsupported filter type %s", filter.Type)
+		}
+		// The index holds one service, so the service code needs no filter
+		if strings.EqualFold(aws.ToString(filter.Field), "ServiceCode") {
+			continue
+		}
+		filters = append(filters, offers.Filter{Field: aws.ToString(filter.Field), Value: aws.ToString(filter.Value)})
+	}
+
+	offer, err := l.index.Offer(serviceCode)
+	if err != nil {
+		return nil, err
+	}
+	products, err := l.index.Query(serviceCode, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	if params.NextToken != nil {
+		start, err = strconv.Atoi(aws.ToString(params.NextToken))
+		if err != nil || start < 0 || start > len(products) {
+			return nil, fmt.Errorf("invalid next token %q", aws.ToString(params.NextToken))
+		}
+	}
+	limit := int(aws.ToInt32(params.MaxResults))
+	if limit <= 0 {
+		limit = defaultMaxResults
+	}
+	end := min(start+limit, len(products))
+
+	output := &awspricing.GetProductsOutput{PriceList: []string{}, FormatVersion: aws.String("aws_v1")}
+	for _, product := range products[start:end] {
+		item, err := product.PriceListItem(offer)
+		if err != nil {
+			return nil, err
+		}
+		output.PriceList = append(output.PriceList, item)
+	}
+	if end < len(products) {
+		output.NextToken = aws.String(strconv.Itoa(end))
+	}
+
+	return output, nil
+}
@@ -0,0 +1,96 @@
+package aws
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// ec2Platform is the operating system and licensing an EC2 instance is
+// priced for, as named by the price list attributes
+type ec2Platform struct {
+	OperatingSystem string
+	LicenseModel    string
+	PreInstalledSw  string
+}
+
+// ec2Platforms maps the platform names used in the AMI platform map to
+// price list attributes
+var ec2Platforms = map[string]ec2Platform{
+	"linux":           {"Linux", "No License required", "NA"},
+	"rhel":            {"RHEL", "No License required", "NA"},
+	"rhel-ha":         {"Red Hat Enterprise Linux with HA", "No License required", "NA"},
+	"suse":            {"SUSE", "No License required", "NA"},
+	"ubuntu-pro":      {"Ubuntu Pro", "No License required", "NA"},
+	"windows":         {"Windows", "No License required", "NA"},
+	"windows-byol":    {"Windows", "Bring your own license", "NA"},
+	"windows-sql-web": {"Windows", "No License required", "SQL Web"},
+	"windows-sql-std": {"Windows", "No License required", "SQL Std"},
+	"windows-sql-ent": {"Windows", "No License required", "SQL Ent"},
+	"linux-sql-web":   {"Linux", "No License required", "SQL Web"},
+	"linux-sql-std":   {"Linux", "No License required", "SQL Std"},
+	"linux-sql-ent":   {"Linux", "No License required", "SQL Ent"},
+	"rhel-sql-std":    {"RHEL", "No License required", "SQL Std"},
+	"rhel-sql-ent":    {"RHEL", "No License required", "SQL Ent"},
+}
+
+// Platforms returns the platform names accepted in the AMI platform map
+func Platforms() []string {
+	names := make([]string, 0, len(ec2Platforms))
+	for name := range ec2Platforms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ec2Placement is how an instance is priced: its platform, its tenancy and
+// where those came from
+type ec2Placement struct {
+	ec2Platform
+	Platform       string // Name of the platform in ec2Platforms
+	PlatformSource string // Resource property the platform was derived from
+	Tenancy        string // Shared, Dedicated or Host
+}
+
+// detectEC2Placement derives the platform and tenancy of an instance from its
+// properties. The platform comes from the AMI platform map, falls back to
+// Windows for instances retrieving their Windows password, and otherwise is
+// Linux.
+func (c *Client) detectEC2Placement(resource *model.Resource) ec2Placement {
+	placement := ec2Placement{Platform: "linux", PlatformSource: "default", Tenancy: "Shared"}
+
+	if ami, ok := resource.Properties["ami"].(string); ok && c.amiPlatforms[strings.ToLower(ami)] != "" {
+		placement.Platform = c.amiPlatforms[strings.ToLower(ami)]
+		placement.PlatformSource = "ami"
+	} else if getPasswordData, _ := resource.Properties["get_password_data"].(bool); getPasswordData {
+		placement.Platform = "windows"
+		placement.PlatformSource = "get_password_data"
+	}
+	placement.ec2Platform = ec2Platforms[placement.Platform]
+
+	// Instances on a Dedicated Host are paid for through the host
+	tenancy, _ := resource.Properties["tenancy"].(string)
+	hostID, _ := resource.Properties["host_id"].(string)
+	switch {
+	case hostID != "" || strings.EqualFold(tenancy, "host"):
+		placement.Tenancy = "Host"
+	case strings.EqualFold(tenancy, "dedicated"):
+		placement.Tenancy = "Dedicated"
+	}
+
+	return placement
+}
+
+// metadata describes the placement for the pricing details of a resource
+func (p ec2Placement) metadata() map[string]string {
+	return map[string]string{
+		"platform":         p.Platform,
+		"platform_source":  p.PlatformSource,
+		"operating_system": p.OperatingSystem,
+		"license_model":    p.LicenseModel,
+		"pre_installed_sw": p.PreInstalledSw,
+		"tenancy":          p.Tenancy,
+	}
+}
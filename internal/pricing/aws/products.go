@@ -0,0 +1,178 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// maxListedCandidates caps the products named in an ambiguous match error
+const maxListedCandidates = 10
+
+// exactMatchServices are the services whose filters pin down a single
+// product. A lookup matching several products there is an error rather than
+// a guess.
+var exactMatchServices = map[string]bool{
+	"AmazonEC2": true,
+	"AmazonRDS": true,
+}
+
+// pricedProduct is a product of a price list with its on-demand price
+type pricedProduct struct {
+	SKU        string
+	Attributes map[string]string
+	Price      float64 // USD per unit, usually per hour
+	Unit       string
+}
+
+// parseProduct extracts the SKU, attributes and on-demand price of a price
+// list item
+func parseProduct(priceListItem string) (pricedProduct, error) {
+	var priceData struct {
+		Product struct {
+			SKU        string            `json:"sku"`
+			Attributes map[string]string `json:"attributes"`
+		} `json:"product"`
+	}
+	if err := json.Unmarshal([]byte(priceListItem), &priceData); err != nil {
+		return pricedProduct{}, fmt.Errorf("failed to parse pricing data: %v", err)
+	}
+
+	tiers, unit, err := parsePriceTiers(priceListItem)
+	if err != nil {
+		return pricedProduct{}, err
+	}
+	if len(tiers) == 0 {
+		return pricedProduct{}, fmt.Errorf("no on-demand price for SKU %s", priceData.Product.SKU)
+	}
+
+	return pricedProduct{
+		SKU:        priceData.Product.SKU,
+		Attributes: priceData.Product.Attributes,
+		Price:      tiers[0].UnitPrice,
+		Unit:       unit,
+	}, nil
+}
+
+// parseProducts parses the products of a price list, skipping repeated SKUs
+// and products without an on-demand price
+func parseProducts(priceList []string) []pricedProduct {
+	var products []pricedProduct
+	seen := make(map[string]bool)
+	for _, priceListItem := range priceList {
+		product, err := parseProduct(priceListItem)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Skipping pricing result: %v\n", err)
+			continue
+		}
+		if seen[product.SKU] {
+			continue
+		}
+		seen[product.SKU] = true
+		products = append(products, product)
+	}
+	return products
+}
+
+// selectProduct picks the product that prices a resource from the products
+// matching its filters. For exactMatchServices exactly one SKU must match;
+// other services use the first product with an on-demand price.
+func selectProduct(resource *model.Resource, serviceCode string, response *awspricing.GetProductsOutput) (pricedProduct, error) {
+	products := parseProducts(response.PriceList)
+	if len(products) == 0 {
+		return pricedProduct{}, pricing.NewError(model.ReasonNoMatch, "no pricing data found for resource: %s", resource.ID)
+	}
+
+	if !exactMatchServices[serviceCode] {
+		return products[0], nil
+	}
+
+	if len(products) > 1 || response.NextToken != nil {
+		return pricedProduct{}, pricing.NewError(model.ReasonAmbiguousMatch, "%d %s products match %s: %s",
+			len(products), serviceCode, resource.Size, describeCandidates(products, response.NextToken != nil))
+	}
+
+	return products[0], nil
+}
+
+// uniqueProduct looks up the one product matching the filters and keep,
+// reading every page of results first. what names the product in errors.
+func (c *Client) uniqueProduct(serviceCode string, filters []types.Filter, what string, keep func(pricedProduct) bool) (pricedProduct, error) {
+	priceList, more, err := c.getAllProducts(serviceCode, filters)
+	if err != nil {
+		return pricedProduct{}, pricing.NewError(model.ReasonAPIError, "failed to get pricing data: %v", err)
+	}
+
+	var products []pricedProduct
+	for _, product := range parseProducts(priceList) {
+		if keep(product) {
+			products = append(products, product)
+		}
+	}
+
+	switch {
+	case len(products) == 0 && more:
+		return pricedProduct{}, pricing.NewError(model.ReasonNoMatch, "no pricing data found for %s in the first %d pages of products", what, maxProductPages)
+	case len(products) == 0:
+		return pricedProduct{}, pricing.NewError(model.ReasonNoMatch, "no pricing data found for %s", what)
+	case len(products) > 1 || more:
+		return pricedProduct{}, pricing.NewError(model.ReasonAmbiguousMatch, "%d %s products match %s: %s",
+			len(products), serviceCode, what, describeCandidates(products, more))
+	}
+	return products[0], nil
+}
+
+// describeCandidates lists products by SKU along with the attributes that
+// tell them apart
+func describeCandidates(products []pricedProduct, more bool) string {
+	var differing []string
+	for name, value := range products[0].Attributes {
+		for _, product := range products[1:] {
+			if product.Attributes[name] != value {
+				differing = append(differing, name)
+				break
+			}
+		}
+	}
+	// Attributes missing from the first product can differ too
+	for _, product := range products[1:] {
+		for name := range product.Attributes {
+			if _, ok := products[0].Attributes[name]; !ok && !slices.Contains(differing, name) {
+				differing = append(differing, name)
+			}
+		}
+	}
+	sort.Strings(differing)
+
+	var candidates []string
+	for i, product := range products {
+		if i == maxListedCandidates {
+			more = true
+			break
+		}
+
+		var attributes []string
+		for _, name := range differing {
+			attributes = append(attributes, name+"="+product.Attributes[name])
+		}
+		candidate := product.SKU
+		if len(attributes) > 0 {
+			candidate += " (" + strings.Join(attributes, ", ") + ")"
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	list := strings.Join(candidates, "; ")
+	if more {
+		list += "; and more"
+	}
+	return list
+}
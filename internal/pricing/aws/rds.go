@@ -0,0 +1,217 @@
+package aws
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// rdsEngine is a database engine as named by the price list attributes
+type rdsEngine struct {
+	DatabaseEngine  string
+	DatabaseEdition string // Set for Oracle and SQL Server
+	LicenseModel    string // License model used when none is given
+}
+
+// rdsEngines maps the engine attribute of aws_db_instance to price list
+// attributes
+var rdsEngines = map[string]rdsEngine{
+	"mariadb":        {"MariaDB", "", "No license required"},
+	"mysql":          {"MySQL", "", "No license required"},
+	"postgres":       {"PostgreSQL", "", "No license required"},
+	"oracle-ee":      {"Oracle", "Enterprise", "Bring your own license"},
+	"oracle-ee-cdb":  {"Oracle", "Enterprise", "Bring your own license"},
+	"oracle-se2":     {"Oracle", "Standard Two", "License included"},
+	"oracle-se2-cdb": {"Oracle", "Standard Two", "License included"},
+	"sqlserver-ee":   {"SQL Server", "Enterprise", "License included"},
+	"sqlserver-ex":   {"SQL Server", "Express", "License included"},
+	"sqlserver-se":   {"SQL Server", "Standard", "License included"},
+	"sqlserver-web":  {"SQL Server", "Web", "License included"},
+}
+
+// rdsLicenseModels maps the license_model attribute to price list values
+var rdsLicenseModels = map[string]string{
+	"bring-your-own-license": "Bring your own license",
+	"license-included":       "License included",
+}
+
+// rdsStorageTypes maps the storage_type attribute to the volume types of
+// database storage, and to the end of the usage type of their provisioned
+// IOPS
+var rdsStorageTypes = map[string]struct {
+	volumeType    string
+	iopsUsageType string // Empty when IOPS are not provisioned separately
+}{
+	"standard": {"Magnetic", ""},
+	"gp2":      {"General Purpose", ""},
+	"gp3":      {"General Purpose-GP3", "PIOPS-gp3"},
+	"io1":      {"Provisioned IOPS", "PIOPS"},
+	"io2":      {"Provisioned IOPS-IO2", "PIOPS-IO2"},
+}
+
+// rdsBackupUsageType ends the usage type of backup storage beyond the free
+// allowance
+const rdsBackupUsageType = "ChargedBackupUsage"
+
+// rdsDatabase is how a database instance is priced
+type rdsDatabase struct {
+	rdsEngine
+	Engine           string // engine attribute
+	DeploymentOption string // Single-AZ or Multi-AZ
+}
+
+// detectRDSDatabase reads the engine, license model and deployment of a
+// database instance. MySQL is assumed when no engine is given.
+func detectRDSDatabase(resource *model.Resource) (rdsDatabase, error) {
+	database := rdsDatabase{Engine: "mysql", DeploymentOption: "Single-AZ"}
+	if engine, ok := resource.Properties["engine"].(string); ok && engine != "" {
+		database.Engine = strings.ToLower(engine)
+	}
+
+	engine, ok := rdsEngines[database.Engine]
+	if !ok {
+		return database, pricing.NewError(model.ReasonUnsupportedType, "unsupported database engine %q for resource: %s", database.Engine, resource.ID)
+	}
+	database.rdsEngine = engine
+
+	// Open source engines need no license whatever license_model says
+	if licenseModel, ok := resource.Properties["license_model"].(string); ok && engine.LicenseModel != "No license required" {
+		if value, ok := rdsLicenseModels[licenseModel]; ok {
+			database.LicenseModel = value
+		}
+	}
+
+	if multiAZ, _ := resource.Properties["multi_az"].(bool); multiAZ {
+		database.DeploymentOption = "Multi-AZ"
+	}
+
+	return database, nil
+}
+
+// metadata describes the database for the pricing details of a resource
+func (d rdsDatabase) metadata() map[string]string {
+	metadata := map[string]string{
+		"engine":            d.Engine,
+		"database_engine":   d.DatabaseEngine,
+		"license_model":     d.LicenseModel,
+		"deployment_option": d.DeploymentOption,
+	}
+	if d.DatabaseEdition != "" {
+		metadata["database_edition"] = d.DatabaseEdition
+	}
+	return metadata
+}
+
+// priceRDSStorage prices the storage, provisioned IOPS and backup storage of a
+// database instance
+func (c *Client) priceRDSStorage(resource *model.Resource, region string) ([]model.PriceComponent, error) {
+	database, err := detectRDSDatabase(resource)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []model.PriceComponent
+
+	// Replicas and instances restored from snapshots may not give a size
+	size := propertyNumber(resource, "allocated_storage", 0)
+	iops := propertyNumber(resource, "iops", 0)
+	if size > 0 {
+		storageType, _ := resource.Properties["storage_type"].(string)
+		if storageType == "" {
+			storageType = "gp2"
+			if iops > 0 {
+				storageType = "io1"
+			}
+		}
+		storage, ok := rdsStorageTypes[storageType]
+		if !ok {
+			return nil, pricing.NewError(model.ReasonUnsupportedType, "unsupported storage type %q for resource: %s", storageType, resource.ID)
+		}
+
+		product, err := c.rdsProduct(region, database, []types.Filter{
+			termMatch("productFamily", "Database Storage"),
+			termMatch("volumeType", storage.volumeType),
+			termMatch("deploymentOption", database.DeploymentOption),
+		}, "", storage.volumeType+" storage")
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, monthlyComponent(fmt.Sprintf("Storage (%s)", storageType), "GB-Mo", product.Price, size))
+
+		// gp3 includes a baseline and is charged for IOPS above it
+		if storageType == "gp3" {
+			iops -= rdsGP3BaselineIOPS(database, size)
+		}
+		if storage.iopsUsageType != "" && iops > 0 {
+			product, err := c.rdsProduct(region, database, []types.Filter{
+				termMatch("productFamily", "Provisioned IOPS"),
+				termMatch("deploymentOption", database.DeploymentOption),
+			}, storage.iopsUsageType, storageType+" provisioned IOPS")
+			if err != nil {
+				return nil, err
+			}
+			components = append(components, monthlyComponent(fmt.Sprintf("Provisioned IOPS (%s)", storageType), "IOPS-Mo", product.Price, iops))
+		}
+	}
+
+	// Backups up to the size of the storage are free; the rest comes from
+	// the usage file
+	if propertyNumber(resource, "backup_retention_period", 1) > 0 && resource.Usage["backup_storage_gb"] > 0 {
+		product, err := c.rdsProduct(region, database, []types.Filter{
+			termMatch("productFamily", "Storage Snapshot"),
+		}, rdsBackupUsageType, "backup storage")
+		if err != nil {
+			return nil, err
+		}
+		components = append(components, monthlyComponent("Backup storage", "GB-Mo", product.Price, resource.Usage["backup_storage_gb"]))
+	}
+
+	return components, nil
+}
+
+// rdsGP3BaselineIOPS returns the IOPS included with gp3 database storage,
+// which rise from 3,000 to 12,000 at 400 GiB (200 GiB for Oracle). SQL
+// Server always gets 3,000.
+func rdsGP3BaselineIOPS(database rdsDatabase, size float64) float64 {
+	threshold := 400.0
+	switch database.DatabaseEngine {
+	case "SQL Server":
+		return 3000
+	case "Oracle":
+		threshold = 200
+	}
+	if size >= threshold {
+		return 12000
+	}
+	return 3000
+}
+
+// rdsProduct looks up the one RDS product for the engine of a database that
+// matches the filters in a region. Products for any engine count as a match,
+// and products sharing the filters are told apart by the end of their usage
+// type.
+func (c *Client) rdsProduct(region string, database rdsDatabase, filters []types.Filter, usageType, what string) (pricedProduct, error) {
+	filters = append([]types.Filter{termMatch("regionCode", region)}, filters...)
+
+	return c.uniqueProduct("AmazonRDS", filters, fmt.Sprintf("%s %s", database.DatabaseEngine, what), func(product pricedProduct) bool {
+		engine := product.Attributes["databaseEngine"]
+		if engine != "" && engine != "Any" && engine != database.DatabaseEngine {
+			return false
+		}
+		return usageType == "" || strings.HasSuffix(product.Attributes["usagetype"], usageType)
+	})
+}
+
+// monthlyComponent builds a price component charged per unit and month
+func monthlyComponent(name, unit string, unitPrice, units float64) model.PriceComponent {
+	return model.PriceComponent{
+		Name:      name,
+		Unit:      unit,
+		UnitPrice: unitPrice,
+		Units:     units,
+		Total:     unitPrice * units,
+	}
+}
@@ -0,0 +1,166 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awspricing "github.com/aws/aws-sdk-go-v2/service/pricing"
+	"github.com/littleworks-inc/cloudcost/internal/pricing/cache"
+)
+
+// recording is a GetProducts request and its response as stored on disk
+type recording struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	ServiceCode string           `json:"service_code"`
+	Filters     []recordedFilter `json:"filters"`
+	MaxResults  int32            `json:"max_results,omitempty"`
+	NextToken   string           `json:"next_token,omitempty"`
+}
+
+type recordedFilter struct {
+	Type  string `json:"type"`
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+type recordedResponse struct {
+	FormatVersion string   `json:"format_version,omitempty"`
+	PriceList     []string `json:"price_list"`
+	NextToken     string   `json:"next_token,omitempty"`
+}
+
+// recordingLister saves every GetProducts request and response to a
+// directory, one file per distinct request, for replayingLister
+type recordingLister struct {
+	next productLister
+	dir  string
+}
+
+// GetProducts forwards the request and records the response. Failed requests
+// are not recorded.
+func (l *recordingLister) GetProducts(ctx context.Context, params *awspricing.GetProductsInput, optFns ...func(*awspricing.Options)) (*awspricing.GetProductsOutput, error) {
+	output, err := l.next.GetProducts(ctx, params, optFns...)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := recording{
+		Request: newRecordedRequest(params),
+		Response: recordedResponse{
+			FormatVersion: aws.ToString(output.FormatVersion),
+			PriceList:     output.PriceList,
+			NextToken:     aws.ToString(output.NextToken),
+		},
+	}
+	if entry.Response.PriceList == nil {
+		entry.Response.PriceList = []string{}
+	}
+
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to record pricing response: %v", err)
+	}
+	if err := cache.WriteFile(recordingPath(l.dir, params), append(content, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to record pricing response: %v", err)
+	}
+
+	return output, nil
+}
+
+// replayingLister answers GetProducts requests from responses saved by
+// recordingLister, without network access
+type replayingLister struct {
+	dir string
+}
+
+// notRecordedError is returned when replaying a request that was never
+// recorded
+type notRecordedError struct {
+	request recordedRequest
+}
+
+// Error returns the error message
+func (e *notRecordedError) Error() string {
+	var filters []string
+	for _, filter := range e.request.Filters {
+		filters = append(filters, filter.Field+"="+filter.Value)
+	}
+	return fmt.Sprintf("no recorded response for %s request (%s); record it again with --pricing-record",
+		e.request.ServiceCode, strings.Join(filters, ", "))
+}
+
+// GetProducts returns the recorded response to the request
+func (l *replayingLister) GetProducts(ctx context.Context, params *awspricing.GetProductsInput, optFns ...func(*awspricing.Options)) (*awspricing.GetProductsOutput, error) {
+	request := newRecordedRequest(params)
+	content, err := os.ReadFile(recordingPath(l.dir, params))
+	if os.IsNotExist(err) {
+		return nil, &notRecordedError{request: request}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to replay pricing response: %v", err)
+	}
+
+	var entry recording
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return nil, fmt.Errorf("failed to replay pricing response: %v", err)
+	}
+
+	// File names use part of a hash, so check the request really matches
+	if !reflect.DeepEqual(entry.Request, request) {
+		return nil, &notRecordedError{request: request}
+	}
+
+	output := &awspricing.GetProductsOutput{PriceList: entry.Response.PriceList}
+	if entry.Response.FormatVersion != "" {
+		output.FormatVersion = aws.String(entry.Response.FormatVersion)
+	}
+	if entry.Response.NextToken != "" {
+		output.NextToken = aws.String(entry.Response.NextToken)
+	}
+	return output, nil
+}
+
+// newRecordedRequest describes a request with its filters in a fixed order
+func newRecordedRequest(params *awspricing.GetProductsInput) recordedRequest {
+	request := recordedRequest{
+		ServiceCode: aws.ToString(params.ServiceCode),
+		Filters:     []recordedFilter{},
+		MaxResults:  aws.ToInt32(params.MaxResults),
+		NextToken:   aws.ToString(params.NextToken),
+	}
+	for _, filter := range params.Filters {
+		request.Filters = append(request.Filters, recordedFilter{
+			Type:  string(filter.Type),
+			Field: aws.ToString(filter.Field),
+			Value: aws.ToString(filter.Value),
+		})
+	}
+	sort.Slice(request.Filters, func(i, j int) bool {
+		a, b := request.Filters[i], request.Filters[j]
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		if a.Value != b.Value {
+			return a.Value < b.Value
+		}
+		return a.Type < b.Type
+	})
+	return request
+}
+
+// recordingPath returns the file of a request, named by its service code and
+// the same content hash as the pricing cache
+func recordingPath(dir string, params *awspricing.GetProductsInput) string {
+	return filepath.Join(dir, aws.ToString(params.ServiceCode)+"-"+productsKey(params)[:16]+".json")
+}
@@ -0,0 +1,465 @@
+package aws
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/pricing/types"
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// usageComponent describes one usage-driven line item of a resource's price
+type usageComponent struct {
+	name        string
+	unit        string
+	serviceCode string
+	filters     func(region string, resource *model.Resource) []types.Filter
+	quantity    func(resource *model.Resource) float64
+	applies     func(resource *model.Resource) bool // nil means always
+}
+
+// usageComponents lists the usage-driven price components for each resource type.
+// Resource types with no hourly base price are priced entirely from these.
+var usageComponents = map[string][]usageComponent{
+	"aws_instance": {
+		dataTransferOutComponent,
+	},
+	"aws_s3_bucket": {
+		{
+			name:        "Storage (Standard)",
+			unit:        "GB-Mo",
+			serviceCode: "AmazonS3",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "Storage"),
+					termMatch("volumeType", "Standard"),
+				}
+			},
+			quantity: usageValue("storage_gb"),
+		},
+		{
+			name:        "PUT, COPY, POST, LIST requests",
+			unit:        "Requests",
+			serviceCode: "AmazonS3",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "API Request"),
+					termMatch("group", "S3-API-Tier1"),
+				}
+			},
+			quantity: usageValue("monthly_put_requests"),
+		},
+		{
+			name:        "GET, SELECT and all other requests",
+			unit:        "Requests",
+			serviceCode: "AmazonS3",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "API Request"),
+					termMatch("group", "S3-API-Tier2"),
+				}
+			},
+			quantity: usageValue("monthly_get_requests"),
+		},
+		dataTransferOutComponent,
+	},
+	"aws_lambda_function": {
+		{
+			name:        "Requests",
+			unit:        "Requests",
+			serviceCode: "AWSLambda",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("group", "AWS-Lambda-Requests"),
+				}
+			},
+			quantity: usageValue("monthly_requests"),
+		},
+		{
+			name:        "Duration",
+			unit:        "GB-Seconds",
+			serviceCode: "AWSLambda",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				group := "AWS-Lambda-Duration"
+				if lambdaArchitecture(resource) == "arm64" {
+					group = "AWS-Lambda-Duration-ARM"
+				}
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("group", group),
+				}
+			},
+			quantity: func(resource *model.Resource) float64 {
+				// GB-seconds = requests * duration in seconds * memory in GB
+				memoryMB := propertyNumber(resource, "memory_size", 128)
+				return resource.Usage["monthly_requests"] *
+					(resource.Usage["request_duration_ms"] / 1000) *
+					(memoryMB / 1024)
+			},
+		},
+	},
+	"aws_dynamodb_table": {
+		{
+			name:        "Write request units",
+			unit:        "WriteRequestUnits",
+			serviceCode: "AmazonDynamoDB",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "Amazon DynamoDB PayPerRequest Throughput"),
+					termMatch("group", "DDB-WriteUnits"),
+				}
+			},
+			quantity: usageValue("monthly_write_request_units"),
+			applies:  isPayPerRequest,
+		},
+		{
+			name:        "Read request units",
+			unit:        "ReadRequestUnits",
+			serviceCode: "AmazonDynamoDB",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "Amazon DynamoDB PayPerRequest Throughput"),
+					termMatch("group", "DDB-ReadUnits"),
+				}
+			},
+			quantity: usageValue("monthly_read_request_units"),
+			applies:  isPayPerRequest,
+		},
+		{
+			name:        "Write capacity units",
+			unit:        "WriteCapacityUnit-Hrs",
+			serviceCode: "AmazonDynamoDB",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "Provisioned IOPS"),
+					termMatch("group", "DDB-WriteUnits"),
+				}
+			},
+			quantity: func(resource *model.Resource) float64 {
+				return propertyNumber(resource, "write_capacity", 0) * 730
+			},
+			applies: func(resource *model.Resource) bool { return !isPayPerRequest(resource) },
+		},
+		{
+			name:        "Read capacity units",
+			unit:        "ReadCapacityUnit-Hrs",
+			serviceCode: "AmazonDynamoDB",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "Provisioned IOPS"),
+					termMatch("group", "DDB-ReadUnits"),
+				}
+			},
+			quantity: func(resource *model.Resource) float64 {
+				return propertyNumber(resource, "read_capacity", 0) * 730
+			},
+			applies: func(resource *model.Resource) bool { return !isPayPerRequest(resource) },
+		},
+		{
+			name:        "Data storage",
+			unit:        "GB-Mo",
+			serviceCode: "AmazonDynamoDB",
+			filters: func(region string, resource *model.Resource) []types.Filter {
+				return []types.Filter{
+					termMatch("regionCode", region),
+					termMatch("productFamily", "Database Storage"),
+					termMatch("volumeType", "Amazon DynamoDB - Indexed DataStore"),
+				}
+			},
+			quantity: usageValue("storage_gb"),
+		},
+	},
+}
+
+// dataTransferOutComponent prices data transferred out to the internet
+var dataTransferOutComponent = usageComponent{
+	name:        "Data transfer out to internet",
+	unit:        "GB",
+	serviceCode: "AWSDataTransfer",
+	filters: func(region string, resource *model.Resource) []types.Filter {
+		return []types.Filter{
+			termMatch("fromRegionCode", region),
+			termMatch("transferType", "AWS Outbound"),
+			termMatch("toLocation", "External"),
+		}
+	},
+	quantity: usageValue("monthly_data_out_gb"),
+	applies: func(resource *model.Resource) bool {
+		_, ok := resource.Usage["monthly_data_out_gb"]
+		return ok
+	},
+}
+
+// dataTransferOutKey is the usage key for internet data transfer
+var dataTransferOutKey = pricing.UsageKey{
+	Name:         "monthly_data_out_gb",
+	Unit:         "GB",
+	Description:  "Monthly data transferred out to the internet",
+	DefaultValue: 10,
+}
+
+// usageKeys lists the usage file keys understood for each resource type
+var usageKeys = map[string][]pricing.UsageKey{
+	"aws_instance": {
+		dataTransferOutKey,
+	},
+	"aws_s3_bucket": {
+		{Name: "storage_gb", Unit: "GB", Description: "Average Standard storage per month", DefaultValue: 100},
+		{Name: "monthly_put_requests", Unit: "requests", Description: "Monthly PUT, COPY, POST and LIST requests", DefaultValue: 100000},
+		{Name: "monthly_get_requests", Unit: "requests", Description: "Monthly GET, SELECT and other requests", DefaultValue: 1000000},
+		dataTransferOutKey,
+	},
+	"aws_lambda_function": {
+		{Name: "monthly_requests", Unit: "requests", Description: "Monthly invocations", DefaultValue: 1000000},
+		{Name: "request_duration_ms", Unit: "ms", Description: "Average duration of each invocation", DefaultValue: 250},
+	},
+	"aws_dynamodb_table": {
+		{Name: "monthly_write_request_units", Unit: "WRU", Description: "Monthly write request units (on-demand tables only)", DefaultValue: 1000000},
+		{Name: "monthly_read_request_units", Unit: "RRU", Description: "Monthly read request units (on-demand tables only)", DefaultValue: 5000000},
+		{Name: "storage_gb", Unit: "GB", Description: "Average table storage per month", DefaultValue: 10},
+	},
+	"aws_db_instance": {
+		{Name: "backup_storage_gb", Unit: "GB", Description: "Backup storage beyond the free allowance of the allocated storage", DefaultValue: 0},
+	},
+}
+
+// UsageKeys returns the usage file keys understood for a resource type
+func (c *Client) UsageKeys(resourceType string) []pricing.UsageKey {
+	return usageKeys[resourceType]
+}
+
+// hasPricedComponents reports whether a resource has components that need a
+// price lookup: attribute-driven components, or usage components with usage
+func hasPricedComponents(resource *model.Resource) bool {
+	if componentPricers[resource.ResourceType] != nil {
+		return true
+	}
+	for _, component := range usageComponents[resource.ResourceType] {
+		if (component.applies == nil || component.applies(resource)) && component.quantity(resource) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// addUsageComponents prices the attribute-driven components of a resource,
+// such as storage, and its usage-driven components, and adds them to any base
+// price already set on it
+func (c *Client) addUsageComponents(resource *model.Resource, region string) error {
+	components := usageComponents[resource.ResourceType]
+	pricer := componentPricers[resource.ResourceType]
+	if len(components) == 0 && pricer == nil {
+		return nil
+	}
+
+	if resource.PricingDetails == nil {
+		resource.PricingDetails = &model.PricingDetails{
+			Currency:      "USD",
+			LastUpdated:   time.Now(),
+			PricingSource: c.source,
+		}
+	}
+
+	monthly := resource.MonthlyPrice
+	if monthly > 0 {
+		resource.PricingDetails.PriceComponents = append(resource.PricingDetails.PriceComponents, model.PriceComponent{
+			Name:      "Instance usage",
+			Unit:      "Hrs",
+			UnitPrice: resource.HourlyPrice,
+			Units:     730,
+			Total:     monthly,
+		})
+	}
+
+	if pricer != nil {
+		attributeComponents, err := pricer(c, resource, region)
+		if err != nil {
+			return err
+		}
+		for _, component := range attributeComponents {
+			resource.PricingDetails.PriceComponents = append(resource.PricingDetails.PriceComponents, component)
+			monthly += component.Total
+		}
+	}
+
+	for _, component := range components {
+		if component.applies != nil && !component.applies(resource) {
+			continue
+		}
+
+		// Components without usage cost nothing, so need no lookup
+		units := component.quantity(resource)
+		if units <= 0 {
+			continue
+		}
+
+		tiers, unit, err := c.getPriceTiers(component.serviceCode, component.filters(region, resource))
+		if err != nil {
+			return fmt.Errorf("failed to price %s for %s: %w", component.name, resource.ID, err)
+		}
+
+		if unit == "" {
+			unit = component.unit
+		}
+
+		total := tieredCost(tiers, units)
+
+		resource.PricingDetails.PriceComponents = append(resource.PricingDetails.PriceComponents, model.PriceComponent{
+			Name:      component.name,
+			Unit:      unit,
+			UnitPrice: total / units,
+			Units:     units,
+			Total:     total,
+		})
+
+		if len(tiers) > 1 {
+			resource.PricingDetails.PricingTiers = append(resource.PricingDetails.PricingTiers, tiers...)
+		}
+
+		monthly += total
+	}
+
+	resource.MonthlyPrice = monthly
+	resource.HourlyPrice = monthly / 730
+	resource.YearlyPrice = monthly * 12
+
+	return nil
+}
+
+// getPriceTiers returns the on-demand price tiers of the first product matching the filters
+func (c *Client) getPriceTiers(serviceCode string, filters []types.Filter) ([]model.PriceTier, string, error) {
+	response, err := c.getProducts(serviceCode, filters)
+	if err != nil {
+		return nil, "", pricing.NewError(model.ReasonAPIError, "failed to get pricing data: %v", err)
+	}
+
+	for _, priceListItem := range response.PriceList {
+		tiers, unit, err := parsePriceTiers(priceListItem)
+		if err == nil && len(tiers) > 0 {
+			return tiers, unit, nil
+		}
+	}
+
+	return nil, "", pricing.NewError(model.ReasonNoMatch, "no pricing data found for %s", serviceCode)
+}
+
+// parsePriceTiers extracts the on-demand price dimensions of a price list item as tiers
+func parsePriceTiers(priceListItem string) ([]model.PriceTier, string, error) {
+	var priceData struct {
+		Terms struct {
+			OnDemand map[string]struct {
+				PriceDimensions map[string]struct {
+					Unit         string            `json:"unit"`
+					BeginRange   string            `json:"beginRange"`
+					EndRange     string            `json:"endRange"`
+					PricePerUnit map[string]string `json:"pricePerUnit"`
+				} `json:"priceDimensions"`
+			} `json:"OnDemand"`
+		} `json:"terms"`
+	}
+	if err := json.Unmarshal([]byte(priceListItem), &priceData); err != nil {
+		return nil, "", fmt.Errorf("failed to parse pricing data: %v", err)
+	}
+
+	var tiers []model.PriceTier
+	var unit string
+	for _, term := range priceData.Terms.OnDemand {
+		for _, dimension := range term.PriceDimensions {
+			price, err := strconv.ParseFloat(dimension.PricePerUnit["USD"], 64)
+			if err != nil {
+				continue
+			}
+
+			tier := model.PriceTier{UnitPrice: price}
+			tier.StartQuantity, _ = strconv.ParseFloat(dimension.BeginRange, 64)
+			if dimension.EndRange != "Inf" {
+				tier.EndQuantity, _ = strconv.ParseFloat(dimension.EndRange, 64)
+			}
+
+			tiers = append(tiers, tier)
+			unit = dimension.Unit
+		}
+
+		// Only the first on-demand term is relevant
+		if len(tiers) > 0 {
+			break
+		}
+	}
+
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].StartQuantity < tiers[j].StartQuantity
+	})
+
+	return tiers, unit, nil
+}
+
+// tieredCost calculates the cost of a quantity across price tiers
+func tieredCost(tiers []model.PriceTier, quantity float64) float64 {
+	var total float64
+	for _, tier := range tiers {
+		if quantity <= tier.StartQuantity {
+			break
+		}
+
+		units := quantity - tier.StartQuantity
+		if tier.EndQuantity > 0 && quantity > tier.EndQuantity {
+			units = tier.EndQuantity - tier.StartQuantity
+		}
+
+		total += units * tier.UnitPrice
+	}
+	return total
+}
+
+// termMatch builds a TERM_MATCH filter for the AWS Pricing API
+func termMatch(field, value string) types.Filter {
+	return types.Filter{
+		Field: aws.String(field),
+		Type:  types.FilterTypeTermMatch,
+		Value: aws.String(value),
+	}
+}
+
+// usageValue returns a quantity function reading a usage file value
+func usageValue(key string) func(resource *model.Resource) float64 {
+	return func(resource *model.Resource) float64 {
+		return resource.Usage[key]
+	}
+}
+
+// propertyNumber returns a numeric resource property or a default value
+func propertyNumber(resource *model.Resource, name string, defaultValue float64) float64 {
+	if value, ok := resource.Properties[name].(float64); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// isPayPerRequest reports whether a DynamoDB table uses on-demand capacity
+func isPayPerRequest(resource *model.Resource) bool {
+	mode, _ := resource.Properties["billing_mode"].(string)
+	return mode == "PAY_PER_REQUEST"
+}
+
+// lambdaArchitecture returns the instruction set architecture of a Lambda function
+func lambdaArchitecture(resource *model.Resource) string {
+	if architectures, ok := resource.Properties["architectures"].([]interface{}); ok && len(architectures) > 0 {
+		if architecture, ok := architectures[0].(string); ok {
+			return architecture
+		}
+	}
+	return "x86_64"
+}
@@ -0,0 +1,277 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// entrySuffix is the file extension of cache entries
+const entrySuffix = ".json"
+
+// tempPrefix starts the names of entries still being written
+const tempPrefix = ".tmp-"
+
+// Cache stores pricing API responses on disk, one file per request, so
+// identical requests are answered locally until the entry expires.
+//
+// Entries are written to a temporary file and renamed into place, so
+// several processes can share a cache directory: readers see either the old
+// entry, the new one or none, never a partial write.
+type Cache struct {
+	dir string
+	ttl time.Duration
+}
+
+// Stats describes the contents of a cache directory
+type Stats struct {
+	Dir        string     `json:"dir"`
+	TTLSeconds int64      `json:"ttl_seconds"`
+	Entries    int        `json:"entries"`
+	Expired    int        `json:"expired"`
+	Bytes      int64      `json:"bytes"`
+	Oldest     *time.Time `json:"oldest,omitempty"`
+	Newest     *time.Time `json:"newest,omitempty"`
+}
+
+// New creates a cache in a directory, which is created on the first write.
+// An empty directory means DefaultDir.
+func New(dir string, ttl time.Duration) *Cache {
+	if dir == "" {
+		dir = DefaultDir()
+	}
+	return &Cache{dir: dir, ttl: ttl}
+}
+
+// DefaultDir returns the cache directory used when none is configured
+func DefaultDir() string {
+	return filepath.Join(os.TempDir(), "cloudcost-cache")
+}
+
+// Key derives a cache key from the parts identifying a request. Callers
+// should pass the parts in a canonical order.
+func Key(parts ...string) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		// Length-prefix each part so different splits cannot collide
+		fmt.Fprintf(hash, "%d:%s;", len(part), part)
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Dir returns the cache directory
+func (c *Cache) Dir() string {
+	return c.dir
+}
+
+// TTL returns how long entries stay valid
+func (c *Cache) TTL() time.Duration {
+	return c.ttl
+}
+
+// Get decodes the entry for a key into value, reporting whether a valid
+// entry was found. Expired and unreadable entries count as missing.
+func (c *Cache) Get(key string, value interface{}) bool {
+	path := c.path(key)
+	info, err := os.Stat(path)
+	if err != nil || c.expired(info) {
+		return false
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(content, value) == nil
+}
+
+// Put stores value as JSON under a key, replacing any existing entry
+func (c *Cache) Put(key string, value interface{}) error {
+	content, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %v", err)
+	}
+	return WriteFile(c.path(key), content)
+}
+
+// WriteFile writes a file atomically: the content goes to a temporary file
+// in the same directory, which is renamed into place, so concurrent readers
+// never see a partial file. Missing directories are created.
+func WriteFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create directory: %v", err)
+	}
+
+	// Write next to the final path so the rename stays on one filesystem
+	temp, err := os.CreateTemp(filepath.Dir(path), tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	defer os.Remove(temp.Name())
+
+	// CreateTemp makes the file private, but the directory may be shared
+	if err := temp.Chmod(0o644); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if _, err := temp.Write(content); err != nil {
+		temp.Close()
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := temp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return nil
+}
+
+// Stats counts the entries in the cache directory
+func (c *Cache) Stats() (Stats, error) {
+	stats := Stats{Dir: c.dir, TTLSeconds: int64(c.ttl / time.Second)}
+	err := c.walk(func(path string, info fs.FileInfo) error {
+		stats.Entries++
+		stats.Bytes += info.Size()
+		if c.expired(info) {
+			stats.Expired++
+		}
+		modified := info.ModTime()
+		if stats.Oldest == nil || modified.Before(*stats.Oldest) {
+			stats.Oldest = &modified
+		}
+		if stats.Newest == nil || modified.After(*stats.Newest) {
+			stats.Newest = &modified
+		}
+		return nil
+	})
+	return stats, err
+}
+
+// Clear removes every entry and returns the number removed
+func (c *Cache) Clear() (int, error) {
+	return c.remove(func(fs.FileInfo) bool { return true })
+}
+
+// Prune removes expired entries and returns the number removed
+func (c *Cache) Prune() (int, error) {
+	return c.remove(c.expired)
+}
+
+// remove deletes the entries matching a condition, along with temporary
+// files left behind by interrupted writes
+func (c *Cache) remove(match func(fs.FileInfo) bool) (int, error) {
+	removed := 0
+	err := c.walk(func(path string, info fs.FileInfo) error {
+		if !match(info) {
+			return nil
+		}
+		// Another process may have removed it first
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove cache entry: %v", err)
+		}
+		removed++
+		return nil
+	})
+	if err != nil {
+		return removed, err
+	}
+
+	// Temporary files older than a minute belong to writers that died
+	err = c.walkShards(func(shard string, entry fs.DirEntry) error {
+		if !strings.HasPrefix(entry.Name(), tempPrefix) {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && time.Since(info.ModTime()) > time.Minute {
+			os.Remove(filepath.Join(c.dir, shard, entry.Name()))
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// walk calls fn for every entry in the cache directory. Only files laid out
+// as path does are entries; anything else in the directory is left alone. A
+// missing directory is an empty cache.
+func (c *Cache) walk(fn func(path string, info fs.FileInfo) error) error {
+	return c.walkShards(func(shard string, entry fs.DirEntry) error {
+		key, ok := strings.CutSuffix(entry.Name(), entrySuffix)
+		if !ok || !isKey(key) || key[:2] != shard {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return fmt.Errorf("failed to read cache directory: %v", err)
+		}
+		return fn(filepath.Join(c.dir, shard, entry.Name()), info)
+	})
+}
+
+// walkShards calls fn for every file in the subdirectories entries are
+// spread over
+func (c *Cache) walkShards(fn func(shard string, entry fs.DirEntry) error) error {
+	shards, err := os.ReadDir(c.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read cache directory: %v", err)
+	}
+
+	for _, shard := range shards {
+		if !shard.IsDir() || len(shard.Name()) != 2 || !isHex(shard.Name()) {
+			continue
+		}
+		entries, err := os.ReadDir(filepath.Join(c.dir, shard.Name()))
+		if err != nil {
+			// Entries can disappear while another process clears the cache
+			if os.IsNotExist(err) {
+				continue
+			}
+			return fmt.Errorf("failed to read cache directory: %v", err)
+		}
+		for _, entry := range entries {
+			if entry.Type().IsRegular() {
+				if err := fn(shard.Name(), entry); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isKey reports whether a name is a key returned by Key
+func isKey(name string) bool {
+	return len(name) == sha256.Size*2 && isHex(name)
+}
+
+// isHex reports whether a string has only lowercase hexadecimal digits
+func isHex(s string) bool {
+	for _, r := range s {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// path returns the file of an entry, spread over subdirectories by the first
+// two characters of the key to keep directories small
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key+entrySuffix)
+}
+
+// expired reports whether an entry is older than the TTL
+func (c *Cache) expired(info fs.FileInfo) bool {
+	return time.Since(info.ModTime()) > c.ttl
+}
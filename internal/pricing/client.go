@@ -1,6 +1,9 @@
 package pricing
 
 import (
+	"errors"
+	"fmt"
+
 	"github.com/littleworks-inc/cloudcost/pkg/model"
 )
 
@@ -15,3 +18,64 @@ type Client interface {
 	// Initialize sets up the pricing client (e.g., authentication)
 	Initialize() error
 }
+
+// UsageKey describes a usage value that a pricing client understands
+type UsageKey struct {
+	Name         string  // Key in the usage file, e.g., "monthly_requests"
+	Unit         string  // e.g., "GB", "requests"
+	Description  string  // Human-readable description
+	DefaultValue float64 // Suggested starting value
+}
+
+// UsageDescriber is implemented by pricing clients that price resources from usage estimates
+type UsageDescriber interface {
+	// UsageKeys returns the usage keys understood for a resource type
+	UsageKeys(resourceType string) []UsageKey
+}
+
+// Error is returned by pricing clients when a resource cannot be priced
+type Error struct {
+	Reason model.UnpricedReason
+	Err    error
+}
+
+// NewError creates a pricing error with a reason
+func NewError(reason model.UnpricedReason, format string, args ...interface{}) error {
+	return &Error{Reason: reason, Err: fmt.Errorf(format, args...)}
+}
+
+// Error returns the error message
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// ReasonFor returns the reason a pricing error occurred, defaulting to an API error
+func ReasonFor(err error) model.UnpricedReason {
+	var pricingErr *Error
+	if errors.As(err, &pricingErr) {
+		return pricingErr.Reason
+	}
+	return model.ReasonAPIError
+}
+
+// Coverage describes how a pricing client handles a resource type
+type Coverage string
+
+// Coverage levels for resource types
+const (
+	CoveragePriced      Coverage = "priced"
+	CoverageFree        Coverage = "free"
+	CoverageUsageBased  Coverage = "usage-based"
+	CoverageUnsupported Coverage = "unsupported"
+)
+
+// CoverageReporter is implemented by pricing clients that can report which resource types they price
+type CoverageReporter interface {
+	// Coverage returns how the client handles a resource type
+	Coverage(resourceType string) Coverage
+}
@@ -0,0 +1,170 @@
+package pricing
+
+import (
+	"time"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+)
+
+// FreeSource is the pricing source recorded for resources that carry no charge
+const FreeSource = "free"
+
+// freeProviders lists Terraform providers whose resources never incur cloud charges
+var freeProviders = map[string]bool{
+	"archive":   true,
+	"external":  true,
+	"http":      true,
+	"local":     true,
+	"null":      true,
+	"random":    true,
+	"terraform": true,
+	"time":      true,
+	"tls":       true,
+}
+
+// freeResourceTypes is the catalog of resource types that carry no charge of
+// their own, by provider. Keep each list sorted when adding entries.
+var freeResourceTypes = map[string]map[string]bool{
+	"aws": setOf(
+		"aws_acm_certificate_validation",
+		"aws_api_gateway_deployment",
+		"aws_api_gateway_integration",
+		"aws_api_gateway_integration_response",
+		"aws_api_gateway_method",
+		"aws_api_gateway_method_response",
+		"aws_api_gateway_resource",
+		"aws_appautoscaling_policy",
+		"aws_appautoscaling_target",
+		"aws_autoscaling_attachment",
+		"aws_autoscaling_policy",
+		"aws_cloudwatch_event_target",
+		"aws_cloudwatch_log_resource_policy",
+		"aws_db_option_group",
+		"aws_db_parameter_group",
+		"aws_db_subnet_group",
+		"aws_default_network_acl",
+		"aws_default_route_table",
+		"aws_default_security_group",
+		"aws_default_subnet",
+		"aws_default_vpc",
+		"aws_ecr_lifecycle_policy",
+		"aws_ecr_repository_policy",
+		"aws_ecs_cluster",
+		"aws_ecs_task_definition",
+		"aws_egress_only_internet_gateway",
+		"aws_eip_association",
+		"aws_elasticache_parameter_group",
+		"aws_elasticache_subnet_group",
+		"aws_iam_access_key",
+		"aws_iam_account_password_policy",
+		"aws_iam_group",
+		"aws_iam_group_membership",
+		"aws_iam_group_policy",
+		"aws_iam_group_policy_attachment",
+		"aws_iam_instance_profile",
+		"aws_iam_openid_connect_provider",
+		"aws_iam_policy",
+		"aws_iam_policy_attachment",
+		"aws_iam_role",
+		"aws_iam_role_policy",
+		"aws_iam_role_policy_attachment",
+		"aws_iam_service_linked_role",
+		"aws_iam_user",
+		"aws_iam_user_policy",
+		"aws_iam_user_policy_attachment",
+		"aws_internet_gateway",
+		"aws_key_pair",
+		"aws_kms_alias",
+		"aws_lambda_alias",
+		"aws_lambda_event_source_mapping",
+		"aws_lambda_permission",
+		"aws_lb_listener",
+		"aws_lb_listener_rule",
+		"aws_lb_target_group",
+		"aws_lb_target_group_attachment",
+		"aws_main_route_table_association",
+		"aws_network_acl",
+		"aws_network_acl_rule",
+		"aws_placement_group",
+		"aws_rds_cluster_parameter_group",
+		"aws_route",
+		"aws_route_table",
+		"aws_route_table_association",
+		"aws_s3_bucket_acl",
+		"aws_s3_bucket_cors_configuration",
+		"aws_s3_bucket_lifecycle_configuration",
+		"aws_s3_bucket_notification",
+		"aws_s3_bucket_ownership_controls",
+		"aws_s3_bucket_policy",
+		"aws_s3_bucket_public_access_block",
+		"aws_s3_bucket_server_side_encryption_configuration",
+		"aws_s3_bucket_versioning",
+		"aws_s3_bucket_website_configuration",
+		"aws_security_group",
+		"aws_security_group_rule",
+		"aws_sns_topic_policy",
+		"aws_sns_topic_subscription",
+		"aws_sqs_queue_policy",
+		"aws_subnet",
+		"aws_volume_attachment",
+		"aws_vpc",
+		"aws_vpc_dhcp_options",
+		"aws_vpc_dhcp_options_association",
+		"aws_vpc_security_group_egress_rule",
+		"aws_vpc_security_group_ingress_rule",
+	),
+	"azurerm": setOf(
+		"azurerm_network_interface_security_group_association",
+		"azurerm_network_security_group",
+		"azurerm_network_security_rule",
+		"azurerm_resource_group",
+		"azurerm_role_assignment",
+		"azurerm_role_definition",
+		"azurerm_route_table",
+		"azurerm_subnet",
+		"azurerm_subnet_network_security_group_association",
+		"azurerm_subnet_route_table_association",
+		"azurerm_user_assigned_identity",
+		"azurerm_virtual_network",
+	),
+	"google": setOf(
+		"google_compute_firewall",
+		"google_compute_network",
+		"google_compute_route",
+		"google_compute_subnetwork",
+		"google_project_iam_binding",
+		"google_project_iam_member",
+		"google_project_service",
+		"google_service_account",
+		"google_service_account_iam_binding",
+		"google_service_account_iam_member",
+		"google_storage_bucket_iam_binding",
+		"google_storage_bucket_iam_member",
+	),
+}
+
+// IsFree reports whether a resource type carries no charge of its own
+func IsFree(provider, resourceType string) bool {
+	return freeProviders[provider] || freeResourceTypes[provider][resourceType]
+}
+
+// SetFree prices a resource at an explicit $0
+func SetFree(resource *model.Resource) {
+	resource.HourlyPrice = 0
+	resource.MonthlyPrice = 0
+	resource.YearlyPrice = 0
+	resource.PricingDetails = &model.PricingDetails{
+		Currency:      "USD",
+		LastUpdated:   time.Now(),
+		PricingSource: FreeSource,
+	}
+}
+
+// setOf builds a lookup set from a list of names
+func setOf(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[name] = true
+	}
+	return set
+}
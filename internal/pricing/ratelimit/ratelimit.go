@@ -0,0 +1,87 @@
+package ratelimit
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// maxInterval caps the spacing between requests after repeated throttling
+const maxInterval = 10 * time.Second
+
+// Limiter spaces out the requests to one provider's API. The spacing starts
+// at the configured rate, doubles whenever the API throttles a request and
+// shrinks back towards the configured rate as requests succeed.
+type Limiter struct {
+	mu       sync.Mutex
+	min      time.Duration // Spacing at the configured rate
+	interval time.Duration // Current spacing
+	next     time.Time     // Earliest start of the next request
+}
+
+// New creates a limiter allowing rate requests per second. A rate of zero or
+// less disables limiting until the API throttles.
+func New(rate float64) *Limiter {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+	return &Limiter{min: interval, interval: interval}
+}
+
+// Wait blocks until the next request may start
+func (l *Limiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Throttled slows requests down after the API rejected one for exceeding
+// its rate limit
+func (l *Limiter) Throttled() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interval = min(max(l.interval*2, 100*time.Millisecond), maxInterval)
+}
+
+// Succeeded speeds requests back up after a request went through
+func (l *Limiter) Succeeded() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.interval = max(l.interval*9/10, l.min)
+}
+
+// Backoff returns how long to wait before retry number attempt (starting at
+// 1): a random duration up to base doubled for each earlier attempt, capped
+// at ceiling. The randomness keeps concurrent callers from retrying in step.
+func Backoff(attempt int, base, ceiling time.Duration) time.Duration {
+	limit := base
+	for i := 1; i < attempt && limit < ceiling; i++ {
+		limit *= 2
+	}
+	limit = min(limit, ceiling)
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int64N(int64(limit))) + 1
+}
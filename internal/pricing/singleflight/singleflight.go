@@ -0,0 +1,49 @@
+package singleflight
+
+import (
+	"errors"
+	"sync"
+)
+
+// call is a function call in progress or completed
+type call struct {
+	done chan struct{}
+	val  interface{}
+	err  error
+}
+
+// Group runs at most one call per key at a time. Callers asking for a key
+// while its call is in progress wait for it and share its result.
+type Group struct {
+	mu    sync.Mutex
+	calls map[string]*call
+}
+
+// Do runs fn for a key, unless a call for the key is already in progress, in
+// which case it waits for that call and returns its result
+func (g *Group) Do(key string, fn func() (interface{}, error)) (interface{}, error) {
+	g.mu.Lock()
+	if g.calls == nil {
+		g.calls = make(map[string]*call)
+	}
+	if c, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-c.done
+		return c.val, c.err
+	}
+
+	// Waiters see this error if fn panics instead of returning
+	c := &call{done: make(chan struct{}), err: errors.New("singleflight: call panicked")}
+	g.calls[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.calls, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	c.val, c.err = fn()
+	return c.val, c.err
+}
@@ -0,0 +1,150 @@
+package usage
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+
+	"github.com/littleworks-inc/cloudcost/internal/pricing"
+	"gopkg.in/yaml.v3"
+)
+
+// Version is the usage file format version written to new files
+const Version = "0.1"
+
+// Entry lists the usage keys understood for one resource address
+type Entry struct {
+	Address string
+	Keys    []pricing.UsageKey
+}
+
+// Merge adds skeleton entries to an existing usage document, keeping every value
+// that is already present. It returns the merged document and the number of keys added.
+func Merge(existing []byte, entries []Entry) ([]byte, int, error) {
+	doc := &yaml.Node{}
+	current := &File{}
+	if len(bytes.TrimSpace(existing)) > 0 {
+		if err := yaml.Unmarshal(existing, doc); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse existing usage file: %v", err)
+		}
+		if err := yaml.Unmarshal(existing, current); err != nil {
+			return nil, 0, fmt.Errorf("failed to parse existing usage file: %v", err)
+		}
+	}
+
+	// Start a new document if there was nothing to merge into
+	if doc.Kind == 0 {
+		doc.Kind = yaml.DocumentNode
+		doc.HeadComment = "Usage estimates for cloudcost. Adjust the values to match your workloads;\n" +
+			"keys ending in \".*\" provide defaults for every resource of that type."
+		doc.Content = []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}
+	}
+
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, 0, fmt.Errorf("usage file must be a YAML mapping")
+	}
+
+	if findKey(root, "version") == nil {
+		addPair(root, scalar("version"), scalar(Version))
+	}
+
+	resourceUsage := findKey(root, "resource_usage")
+	if resourceUsage == nil || resourceUsage.Kind != yaml.MappingNode {
+		if resourceUsage == nil {
+			resourceUsage = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			addPair(root, scalar("resource_usage"), resourceUsage)
+		} else {
+			// Replace an empty "resource_usage:" value with a mapping
+			*resourceUsage = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+	}
+
+	if current.ResourceUsage == nil {
+		current.ResourceUsage = make(map[string]map[string]float64)
+	}
+
+	added := 0
+	for _, entry := range entries {
+		// Values may already be supplied by the address itself or a wildcard default
+		supplied := current.Lookup(entry.Address)
+
+		var missing []pricing.UsageKey
+		for _, key := range entry.Keys {
+			if _, ok := supplied[key.Name]; !ok {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) == 0 {
+			continue
+		}
+
+		values := findKey(resourceUsage, entry.Address)
+		if values == nil || values.Kind != yaml.MappingNode {
+			if values == nil {
+				values = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				addPair(resourceUsage, scalar(entry.Address), values)
+			} else {
+				*values = yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			}
+		}
+
+		for _, key := range missing {
+			// Entries may repeat a key
+			if _, ok := current.ResourceUsage[entry.Address][key.Name]; ok {
+				continue
+			}
+
+			value := &yaml.Node{
+				Kind:        yaml.ScalarNode,
+				Tag:         "!!float",
+				Value:       strconv.FormatFloat(key.DefaultValue, 'f', -1, 64),
+				LineComment: fmt.Sprintf("%s, %s", key.Unit, key.Description),
+			}
+			if key.DefaultValue == float64(int64(key.DefaultValue)) {
+				value.Tag = "!!int"
+			}
+
+			addPair(values, scalar(key.Name), value)
+			added++
+
+			// Record the key so repeated addresses do not add it again
+			if current.ResourceUsage[entry.Address] == nil {
+				current.ResourceUsage[entry.Address] = make(map[string]float64)
+			}
+			current.ResourceUsage[entry.Address][key.Name] = key.DefaultValue
+		}
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, 0, fmt.Errorf("failed to write usage file: %v", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, 0, fmt.Errorf("failed to write usage file: %v", err)
+	}
+
+	return buf.Bytes(), added, nil
+}
+
+// findKey returns the value node for a key in a mapping node
+func findKey(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// addPair appends a key/value pair to a mapping node
+func addPair(mapping *yaml.Node, key, value *yaml.Node) {
+	mapping.Content = append(mapping.Content, key, value)
+}
+
+// scalar creates a string scalar node
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
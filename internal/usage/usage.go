@@ -0,0 +1,99 @@
+package usage
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/littleworks-inc/cloudcost/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// File represents a usage file supplying usage estimates for usage-based resources.
+//
+// Entries in ResourceUsage are keyed by resource address (e.g., "aws_s3_bucket.logs").
+// Keys containing "*" are wildcards (e.g., "aws_lambda_function.*") and provide
+// defaults for every matching resource; values for an exact address override them.
+type File struct {
+	Version       string                        `yaml:"version"`
+	ResourceUsage map[string]map[string]float64 `yaml:"resource_usage"`
+}
+
+// Load reads and parses a usage file
+func Load(filename string) (*File, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read usage file %s: %v", filename, err)
+	}
+
+	f := &File{}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse usage file %s: %v", filename, err)
+	}
+
+	if f.ResourceUsage == nil {
+		f.ResourceUsage = make(map[string]map[string]float64)
+	}
+
+	return f, nil
+}
+
+// Lookup returns the usage values for a resource address, merging wildcard
+// defaults with values for the exact address
+func (f *File) Lookup(address string) map[string]float64 {
+	// Collect wildcard patterns matching this address, least specific first
+	var patterns []string
+	for key := range f.ResourceUsage {
+		if !strings.Contains(key, "*") {
+			continue
+		}
+		if matched, err := path.Match(key, address); err == nil && matched {
+			patterns = append(patterns, key)
+		}
+	}
+	sort.Slice(patterns, func(i, j int) bool {
+		if len(patterns[i]) != len(patterns[j]) {
+			return len(patterns[i]) < len(patterns[j])
+		}
+		return patterns[i] < patterns[j]
+	})
+
+	values := make(map[string]float64)
+	for _, pattern := range patterns {
+		for key, value := range f.ResourceUsage[pattern] {
+			values[key] = value
+		}
+	}
+
+	// Exact address values take precedence over wildcard defaults
+	for key, value := range f.ResourceUsage[address] {
+		values[key] = value
+	}
+
+	if len(values) == 0 {
+		return nil
+	}
+
+	return values
+}
+
+// Apply attaches usage values to the matching resources
+func (f *File) Apply(resources []model.Resource) {
+	for i := range resources {
+		resource := &resources[i]
+
+		values := f.Lookup(resource.ID)
+		if values == nil {
+			continue
+		}
+
+		if resource.Usage == nil {
+			resource.Usage = make(map[string]float64)
+		}
+		for key, value := range values {
+			resource.Usage[key] = value
+		}
+	}
+}
@@ -8,14 +8,14 @@ import (
 )
 
 func main() {
-	fmt.Println(`
+	fmt.Fprintln(os.Stderr, `
  ______     __         ______     __  __     _____     ______     ______     ______     ______  
 /\  ___\   /\ \       /\  __ \   /\ \/\ \   /\  __-.  /\  ___\   /\  __ \   /\  ___\   /\__  _\ 
 \ \ \____  \ \ \____  \ \ \/\ \  \ \ \_\ \  \ \ \/\ \ \ \ \____  \ \ \/\ \  \ \___  \  \/_/\ \/ 
  \ \_____\  \ \_____\  \ \_____\  \ \_____\  \ \____-  \ \_____\  \ \_____\  \/\_____\    \ \_\ 
   \/_____/   \/_____/   \/_____/   \/_____/   \/____/   \/_____/   \/_____/   \/_____/     \/_/ 
                                                                                                  
-Cloud Cost Estimator for Infrastructure-as-Code (v` + cmd.Version + `)
+Cloud Cost Estimator for Infrastructure-as-Code (v`+cmd.Version+`)
 `)
 
 	if err := cmd.Execute(); err != nil {
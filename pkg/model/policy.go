@@ -0,0 +1,34 @@
+package model
+
+// PolicyKind groups budget and policy checks
+type PolicyKind string
+
+// Kinds of checks
+const (
+	PolicyKindBudget PolicyKind = "budget"
+	PolicyKindPolicy PolicyKind = "policy"
+)
+
+// PolicyResult is the outcome of one budget or policy check
+type PolicyResult struct {
+	Check       string       `json:"check"` // e.g., "monthly_budget"
+	Kind        PolicyKind   `json:"kind"`
+	Description string       `json:"description"`
+	Passed      bool         `json:"passed"`
+	Message     string       `json:"message"`
+	Actual      float64      `json:"actual"`                // Checked value, e.g., the monthly cost
+	Limit       float64      `json:"limit"`                 // Configured limit
+	ResourceID  string       `json:"resource_id,omitempty"` // Resource the result points at, if any
+	Source      *SourceRange `json:"source,omitempty"`      // Where that resource is defined
+}
+
+// PolicyFailures returns the checks that did not pass
+func (r *Report) PolicyFailures() []PolicyResult {
+	var failures []PolicyResult
+	for _, result := range r.PolicyResults {
+		if !result.Passed {
+			failures = append(failures, result)
+		}
+	}
+	return failures
+}
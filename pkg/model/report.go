@@ -36,6 +36,9 @@ type Report struct {
 	PriceDiff        float64        `json:"price_diff,omitempty"`
 	PriceDiffPercent float64        `json:"price_diff_percent,omitempty"`
 
+	// Budget and policy checks
+	PolicyResults []PolicyResult `json:"policy_results,omitempty"`
+
 	// Errors and warnings
 	Errors   []string `json:"errors,omitempty"`
 	Warnings []string `json:"warnings,omitempty"`
@@ -97,24 +100,41 @@ func NewReport() *Report {
 
 // AddResource adds a resource to the report and updates totals
 func (r *Report) AddResource(resource Resource) {
+	resource.CalculateTotals()
 	r.Resources = append(r.Resources, resource)
+	r.addToTotals(&resource)
+}
+
+// AddToTotals adds a resource to the totals and breakdowns without keeping it
+// in the report, for callers that stream resources instead of collecting them
+func (r *Report) AddToTotals(resource *Resource) {
+	resource.CalculateTotals()
+	r.addToTotals(resource)
+}
+
+// addToTotals adds a resource's total prices to the report totals and
+// breakdowns. Unpriced resources are left out.
+func (r *Report) addToTotals(resource *Resource) {
+	if resource.Unpriced != nil {
+		return
+	}
 
 	// Update totals
-	r.TotalHourly += resource.HourlyPrice * float64(resource.Quantity)
-	r.TotalMonthly += resource.MonthlyPrice * float64(resource.Quantity)
-	r.TotalYearly += resource.YearlyPrice * float64(resource.Quantity)
+	r.TotalHourly += resource.TotalHourly
+	r.TotalMonthly += resource.TotalMonthly
+	r.TotalYearly += resource.TotalYearly
 
 	// Update breakdowns
-	r.ByProvider[resource.Provider] += resource.MonthlyPrice * float64(resource.Quantity)
-	r.ByResourceType[resource.ResourceType] += resource.MonthlyPrice * float64(resource.Quantity)
-	r.ByRegion[resource.Region] += resource.MonthlyPrice * float64(resource.Quantity)
+	r.ByProvider[resource.Provider] += resource.TotalMonthly
+	r.ByResourceType[resource.ResourceType] += resource.TotalMonthly
+	r.ByRegion[resource.Region] += resource.TotalMonthly
 
 	// Update tag breakdowns
 	for key, value := range resource.Tags {
 		if _, ok := r.ByTag[key]; !ok {
 			r.ByTag[key] = make(map[string]float64)
 		}
-		r.ByTag[key][value] += resource.MonthlyPrice * float64(resource.Quantity)
+		r.ByTag[key][value] += resource.TotalMonthly
 	}
 }
 
@@ -133,6 +153,28 @@ func (r *Report) AddSuggestion(suggestion Suggestion) {
 	r.Suggestions = append(r.Suggestions, suggestion)
 }
 
+// UnpricedCount returns the number of resources that could not be priced
+func (r *Report) UnpricedCount() int {
+	count := 0
+	for _, resource := range r.Resources {
+		if resource.Unpriced != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// UnpricedByReason groups the resources that could not be priced by reason
+func (r *Report) UnpricedByReason() map[UnpricedReason][]Resource {
+	groups := make(map[UnpricedReason][]Resource)
+	for _, resource := range r.Resources {
+		if resource.Unpriced != nil {
+			groups[resource.Unpriced.Reason] = append(groups[resource.Unpriced.Reason], resource)
+		}
+	}
+	return groups
+}
+
 // Summarize calculates summary information for the report
 func (r *Report) Summarize() {
 	// Reset totals
@@ -147,23 +189,9 @@ func (r *Report) Summarize() {
 	r.ByTag = make(map[string]map[string]float64)
 
 	// Recalculate everything
-	for _, resource := range r.Resources {
-		// Update totals
-		r.TotalHourly += resource.HourlyPrice * float64(resource.Quantity)
-		r.TotalMonthly += resource.MonthlyPrice * float64(resource.Quantity)
-		r.TotalYearly += resource.YearlyPrice * float64(resource.Quantity)
-
-		// Update breakdowns
-		r.ByProvider[resource.Provider] += resource.MonthlyPrice * float64(resource.Quantity)
-		r.ByResourceType[resource.ResourceType] += resource.MonthlyPrice * float64(resource.Quantity)
-		r.ByRegion[resource.Region] += resource.MonthlyPrice * float64(resource.Quantity)
-
-		// Update tag breakdowns
-		for key, value := range resource.Tags {
-			if _, ok := r.ByTag[key]; !ok {
-				r.ByTag[key] = make(map[string]float64)
-			}
-			r.ByTag[key][value] += resource.MonthlyPrice * float64(resource.Quantity)
-		}
+	for i := range r.Resources {
+		resource := &r.Resources[i]
+		resource.CalculateTotals()
+		r.addToTotals(resource)
 	}
 }
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"path/filepath"
 	"time"
 )
 
@@ -14,15 +15,31 @@ type Resource struct {
 	Size           string                 `json:"size"`     // e.g., "t3.micro", "Standard_B2s"
 	Quantity       int                    `json:"quantity"` // Number of instances
 	Tags           map[string]string      `json:"tags,omitempty"`
-	Properties     map[string]interface{} `json:"properties,omitempty"` // Additional properties
-	HourlyPrice    float64                `json:"hourly_price,omitempty"`
-	MonthlyPrice   float64                `json:"monthly_price,omitempty"`
-	YearlyPrice    float64                `json:"yearly_price,omitempty"`
+	Properties     map[string]interface{} `json:"properties,omitempty"`    // Additional properties
+	Usage          map[string]float64     `json:"usage,omitempty"`         // Usage estimates, e.g., "monthly_requests"
+	HourlyPrice    float64                `json:"hourly_price,omitempty"`  // Price of a single instance
+	MonthlyPrice   float64                `json:"monthly_price,omitempty"` // Price of a single instance
+	YearlyPrice    float64                `json:"yearly_price,omitempty"`  // Price of a single instance
+	TotalHourly    float64                `json:"total_hourly,omitempty"`  // HourlyPrice * Quantity
+	TotalMonthly   float64                `json:"total_monthly,omitempty"` // MonthlyPrice * Quantity
+	TotalYearly    float64                `json:"total_yearly,omitempty"`  // YearlyPrice * Quantity
 	PricingDetails *PricingDetails        `json:"pricing_details,omitempty"`
+	Unpriced       *Unpriced              `json:"unpriced,omitempty"`  // Set when the resource could not be priced
+	Warnings       []string               `json:"warnings,omitempty"`  // Parts of the resource left out of its price
+	Source         *SourceRange           `json:"source,omitempty"`    // Where the resource is defined
 	ParentID       string                 `json:"parent_id,omitempty"` // For resources that belong to others
 	Children       []string               `json:"children,omitempty"`  // Child resource IDs
 }
 
+// SourceRange is the location of a resource definition in an IaC file
+type SourceRange struct {
+	Filename    string `json:"filename"`
+	StartLine   int    `json:"start_line"`
+	StartColumn int    `json:"start_column"`
+	EndLine     int    `json:"end_line"`
+	EndColumn   int    `json:"end_column"`
+}
+
 // PricingDetails contains detailed pricing information
 type PricingDetails struct {
 	Currency        string            `json:"currency"`
@@ -45,12 +62,49 @@ type PriceTier struct {
 
 // PriceComponent represents one component of a resource's price
 type PriceComponent struct {
-	Name      string  `json:"name"` // e.g., "Compute", "Storage", "Network"
+	Name      string  `json:"name"`           // e.g., "Compute", "Storage", "Network"
+	Unit      string  `json:"unit,omitempty"` // e.g., "Hrs", "GB-Mo", "Requests"
 	UnitPrice float64 `json:"unit_price"`
 	Units     float64 `json:"units"`
 	Total     float64 `json:"total"`
 }
 
+// UnpricedReason explains why a resource could not be priced
+type UnpricedReason string
+
+// Reasons a resource could not be priced
+const (
+	ReasonUnsupportedType UnpricedReason = "unsupported_type"
+	ReasonMissingSize     UnpricedReason = "missing_size"
+	ReasonAPIError        UnpricedReason = "api_error"
+	ReasonNoMatch         UnpricedReason = "no_match"
+	ReasonAmbiguousMatch  UnpricedReason = "ambiguous_match"
+)
+
+// Description returns a human-readable description of the reason
+func (r UnpricedReason) Description() string {
+	switch r {
+	case ReasonUnsupportedType:
+		return "Unsupported resource type"
+	case ReasonMissingSize:
+		return "Missing size"
+	case ReasonAPIError:
+		return "Pricing API error"
+	case ReasonNoMatch:
+		return "No matching price"
+	case ReasonAmbiguousMatch:
+		return "Ambiguous price match"
+	default:
+		return string(r)
+	}
+}
+
+// Unpriced records why a resource could not be priced
+type Unpriced struct {
+	Reason  UnpricedReason `json:"reason"`
+	Message string         `json:"message"`
+}
+
 // NewResource creates a new resource with default values
 func NewResource() Resource {
 	return Resource{
@@ -68,3 +122,21 @@ func (r *Resource) CalculateDerivedPrices() {
 		r.YearlyPrice = r.HourlyPrice * 8760 // 365 days * 24 hours
 	}
 }
+
+// CalculateTotals calculates the total prices across all instances of the resource
+func (r *Resource) CalculateTotals() {
+	quantity := float64(r.Quantity)
+	r.TotalHourly = r.HourlyPrice * quantity
+	r.TotalMonthly = r.MonthlyPrice * quantity
+	r.TotalYearly = r.YearlyPrice * quantity
+}
+
+// Module returns the module defining the resource: the directory of the file
+// it is declared in, as recorded by the parser. Resources without a source
+// have no module.
+func (r *Resource) Module() string {
+	if r.Source == nil {
+		return ""
+	}
+	return filepath.ToSlash(filepath.Dir(r.Source.Filename))
+}